@@ -0,0 +1,171 @@
+//   Copyright 2018 Duncan Jones
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package qif
+
+import (
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// AccountType indicates the type of register a transaction was read from.
+type AccountType int
+
+const (
+	UnknownAccountType AccountType = iota
+	BankAccount
+	CashAccount
+	CreditCardAccount
+	InvestmentAccount
+	AssetAccount
+	LiabilityAccount
+)
+
+// String returns the name used for the account type in QIF headers (e.g.
+// "CCard" or "Oth A").
+func (t AccountType) String() string {
+	for header, accountType := range registerHeaders {
+		if accountType == t {
+			return strings.TrimPrefix(header, typePrefix)
+		}
+	}
+
+	return "Unknown"
+}
+
+// An Account describes an account in a Quicken file. Accounts are read from
+// !Account sections and own the transactions that follow them.
+type Account interface {
+
+	// Name of the account.
+	Name() string
+
+	// Type of the account, as written in the file (e.g. "Bank", "CCard" or
+	// "Invst").
+	Type() string
+
+	// Description of the account.
+	Description() string
+
+	// CreditLimit stores the credit limit, with the number of decimal places
+	// used by Currency. This is only present for credit card accounts.
+	CreditLimit() Decimal
+
+	// Balance stores the statement balance, with the number of decimal places
+	// used by Currency.
+	Balance() Decimal
+
+	// BalanceDate contains the date of the statement balance.
+	BalanceDate() time.Time
+
+	// Currency is the ISO 4217 code of the account's currency, taken from
+	// Config.AccountCurrencies or Config.Currency. It is empty if no currency
+	// was configured.
+	Currency() string
+
+	// RawRecord gives access to the original data of the account.
+	RawRecord
+}
+
+type account struct {
+	rawData
+	name        string
+	accountType string
+	description string
+	creditLimit Decimal
+	balance     Decimal
+	balanceDate time.Time
+	currency    string
+}
+
+func (a *account) Name() string {
+	return a.name
+}
+
+func (a *account) Type() string {
+	return a.accountType
+}
+
+func (a *account) Description() string {
+	return a.description
+}
+
+func (a *account) CreditLimit() Decimal {
+	return a.creditLimit
+}
+
+func (a *account) Balance() Decimal {
+	return a.balance
+}
+
+func (a *account) BalanceDate() time.Time {
+	return a.balanceDate
+}
+
+func (a *account) Currency() string {
+	return a.currency
+}
+
+func (a *account) parseAccountField(line string, config Config) error {
+	if line == "" {
+		return errors.New("line is empty")
+	}
+
+	switch line[0] {
+	case 'N':
+		a.name = line[1:]
+
+		// Amounts in the account record use the account's own currency, if
+		// they follow the name
+		a.currency = config.currency(a)
+		return nil
+
+	case 'T':
+		a.accountType = line[1:]
+		return nil
+
+	case 'D':
+		a.description = line[1:]
+		return nil
+
+	case 'L':
+		amt, err := parseAmount(line[1:], a.currency, config)
+		if err != nil {
+			return errors.Wrap(err, "failed to parse credit limit")
+		}
+		a.creditLimit = amt
+		return nil
+
+	case '$':
+		amt, err := parseAmount(line[1:], a.currency, config)
+		if err != nil {
+			return errors.Wrap(err, "failed to parse balance")
+		}
+		a.balance = amt
+		return nil
+
+	case '/':
+		date, err := parseDate(line[1:], config)
+		if err != nil {
+			return errors.Wrap(err, "failed to parse balance date")
+		}
+		a.balanceDate = date
+		return nil
+
+	default:
+		return UnsupportedFieldError{Line: line}
+	}
+}
@@ -0,0 +1,80 @@
+//   Copyright 2018 Duncan Jones
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package qif
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestAccountFields(t *testing.T) {
+	a := &account{}
+
+	lines := []string{
+		"NVisa",
+		"TCCard",
+		"DMy credit card",
+		"L5,000.00",
+		"$-1,234.56",
+		"/12/31/17",
+	}
+
+	for _, l := range lines {
+		err := a.parseAccountField(l, Config{})
+		require.NoError(t, err)
+	}
+
+	date, err := time.Parse("01/02/06", "12/31/17")
+	require.NoError(t, err)
+
+	assert.Equal(t, "Visa", a.Name())
+	assert.Equal(t, "CCard", a.Type())
+	assert.Equal(t, "My credit card", a.Description())
+	assert.Equal(t, NewDecimal(500000, 2), a.CreditLimit())
+	assert.Equal(t, NewDecimal(-123456, 2), a.Balance())
+	assert.Equal(t, date, a.BalanceDate())
+}
+
+func TestBadAccountLine(t *testing.T) {
+	a := &account{}
+	err := a.parseAccountField("Z1234", Config{})
+
+	_, ok := err.(UnsupportedFieldError)
+	assert.True(t, ok)
+}
+
+func TestAccountEmptyLine(t *testing.T) {
+	a := &account{}
+	err := a.parseAccountField("", Config{})
+	assert.Error(t, err)
+}
+
+func TestAccountTypeString(t *testing.T) {
+	vectors := map[AccountType]string{
+		BankAccount:        "Bank",
+		CashAccount:        "Cash",
+		CreditCardAccount:  "CCard",
+		InvestmentAccount:  "Invst",
+		AssetAccount:       "Oth A",
+		LiabilityAccount:   "Oth L",
+		UnknownAccountType: "Unknown",
+	}
+
+	for k, v := range vectors {
+		assert.Equal(t, v, k.String())
+	}
+}
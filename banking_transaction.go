@@ -14,10 +14,14 @@
 
 package qif
 
-import "github.com/pkg/errors"
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
 
 // A BankingTransaction contains the information associated with non-investment
-// transactions (i.e. Cash, Bank and CCard account types).
+// transactions (i.e. Cash, Bank, CCard, Oth A and Oth L account types).
 type BankingTransaction interface {
 	Transaction
 
@@ -38,9 +42,18 @@ type BankingTransaction interface {
 	// special sixth line.
 	AddressMessage() string
 
-	// Category of the transaction.
+	// Category of the transaction, excluding any class.
 	Category() string
 
+	// Class of the transaction. Quicken stores this alongside the category in
+	// the form "Category/Class".
+	Class() string
+
+	// CategoryRef contains the category and class of the transaction in
+	// parsed form. It indicates whether the transaction is a transfer to
+	// another account.
+	CategoryRef() CategoryRef
+
 	// Splits contains zero or more fragments of the transaction (AFAIK).
 	Splits() []Split
 }
@@ -52,6 +65,7 @@ type bankingTransaction struct {
 	address        []string
 	addressMessage string
 	category       string
+	class          string
 	splits         []Split
 }
 
@@ -75,6 +89,14 @@ func (t *bankingTransaction) Category() string {
 	return t.category
 }
 
+func (t *bankingTransaction) Class() string {
+	return t.class
+}
+
+func (t *bankingTransaction) CategoryRef() CategoryRef {
+	return ParseCategoryRef(joinCategory(t.category, t.class))
+}
+
 func (t *bankingTransaction) Splits() []Split {
 	return t.splits
 }
@@ -114,7 +136,7 @@ func (t *bankingTransaction) parseBankingTransactionField(line string,
 		}
 		return nil
 	case 'L':
-		t.category = line[1:]
+		t.category, t.class = splitCategory(line[1:])
 		return nil
 
 		// These split fields must be in order, based on statement "The
@@ -122,8 +144,11 @@ func (t *bankingTransaction) parseBankingTransactionField(line string,
 
 	case 'S': // Category
 		split := Split{}
-		cat := line[1:]
+		cat, class := splitCategory(line[1:])
 		split.Category = &cat
+		if class != "" {
+			split.Class = &class
+		}
 		t.splits = append(t.splits, split)
 		return nil
 	case 'E': // Memo
@@ -140,7 +165,7 @@ func (t *bankingTransaction) parseBankingTransactionField(line string,
 		return nil
 
 	case '$': // Amount
-		amt, err := parseAmount(line[1:])
+		amt, err := parseAmount(line[1:], t.currency, config)
 		if err != nil {
 			return errors.Wrap(err, "failed to parse split amount")
 		}
@@ -154,9 +179,24 @@ func (t *bankingTransaction) parseBankingTransactionField(line string,
 		t.splits[len(t.splits)-1].Amount = &amt
 		return nil
 
+	case '%': // Percentage
+		pct, err := parseNumber(strings.TrimSuffix(
+			strings.TrimSpace(line[1:]), "%"), config)
+		if err != nil {
+			return errors.Wrap(err, "failed to parse split percentage")
+		}
+
+		// This follows the amount of its split, so only starts a new split if
+		// there isn't an existing split or it already has a '%' field.
+		if len(t.splits) == 0 || t.splits[len(t.splits)-1].Percent != nil {
+			t.splits = append(t.splits, Split{})
+		}
+
+		t.splits[len(t.splits)-1].Percent = &pct
+		return nil
+
 	default:
-		return UnsupportedFieldError(
-			errors.Errorf("cannot process line '%s'", line))
+		return UnsupportedFieldError{Line: line}
 	}
 }
 
@@ -164,13 +204,119 @@ func (t *bankingTransaction) parseBankingTransactionField(line string,
 // description.
 type Split struct {
 
-	// Category of this transaction split.
+	// Category of this transaction split, excluding any class.
 	Category *string
 
+	// Class of this transaction split.
+	Class *string
+
 	// Memo is a string description of the transaction split.
 	Memo *string
 
-	// Amount stores the transaction split value in minor currency units. For
-	// instance, a $12.99 transaction will be 1299.
-	Amount *int
+	// Amount stores the transaction split value, with the number of decimal
+	// places used by the transaction's currency.
+	Amount *Decimal
+
+	// Percent is the percentage of the transaction amount allocated to the
+	// split (e.g. 25 for a quarter), if the split was entered as a percentage.
+	Percent *Decimal
+}
+
+// CheckSplits verifies that the split amounts of a transaction add up to its
+// total amount. Splits without an amount count as zero. A *SplitSumError is
+// returned if the amounts differ, and nil if they match or there are no
+// splits.
+func CheckSplits(tx BankingTransaction) error {
+	if len(tx.Splits()) == 0 {
+		return nil
+	}
+
+	sum, err := sumSplits(tx.Splits())
+	if err != nil {
+		return err
+	}
+
+	if !sum.Equal(tx.Amount()) {
+		return &SplitSumError{Total: tx.Amount(), Sum: sum}
+	}
+
+	return nil
+}
+
+// sumSplits adds up the split amounts, returning an error if the sum
+// overflows.
+func sumSplits(splits []Split) (Decimal, error) {
+	return checkOverflow(func() (sum Decimal) {
+		for _, s := range splits {
+			if s.Amount != nil {
+				sum = sum.Add(*s.Amount)
+			}
+		}
+		return
+	})
+}
+
+// balanceSplits appends a split without a category holding the difference
+// between the total amount and the sum of the splits, if they differ.
+func (t *bankingTransaction) balanceSplits() error {
+	err := CheckSplits(t)
+
+	sumErr, ok := err.(*SplitSumError)
+	if !ok {
+		return err
+	}
+
+	remainder, err := checkOverflow(func() Decimal {
+		return sumErr.Total.Sub(sumErr.Sum)
+	})
+	if err != nil {
+		return err
+	}
+
+	t.splits = append(t.splits, Split{Amount: &remainder})
+	return nil
+}
+
+// CategoryRef returns the category and class of the split in parsed form. The
+// result is nil if the split has no category.
+func (s Split) CategoryRef() *CategoryRef {
+	if s.Category == nil {
+		return nil
+	}
+
+	class := ""
+	if s.Class != nil {
+		class = *s.Class
+	}
+
+	ref := ParseCategoryRef(joinCategory(*s.Category, class))
+	return &ref
+}
+
+// splitCategory separates a field of the form "Category/Class" into its
+// category and class.
+func splitCategory(s string) (category, class string) {
+	// Account names in transfers may themselves contain a slash
+	start := 0
+	if strings.HasPrefix(s, "[") {
+		if end := strings.Index(s, "]"); end >= 0 {
+			start = end + 1
+		}
+	}
+
+	i := strings.Index(s[start:], "/")
+	if i < 0 {
+		return s, ""
+	}
+
+	return s[:start+i], s[start+i+1:]
+}
+
+// joinCategory is the inverse of splitCategory.
+func joinCategory(category, class string) string {
+	if class == "" {
+		return category
+	}
+
+	return category + "/" + class
 }
@@ -17,6 +17,7 @@ package qif
 import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"math"
 	"testing"
 )
 
@@ -50,6 +51,53 @@ func TestCheckCategory(t *testing.T) {
 	assert.Equal(t, category, tx.Category())
 }
 
+func TestCheckCategoryClass(t *testing.T) {
+	tx := &bankingTransaction{}
+
+	err := tx.parseBankingTransactionField("LAuto:Fuel/Business", Config{})
+	require.NoError(t, err)
+
+	assert.Equal(t, "Auto:Fuel", tx.Category())
+	assert.Equal(t, "Business", tx.Class())
+
+	ref := tx.CategoryRef()
+	assert.Equal(t, []string{"Auto", "Fuel"}, ref.Path)
+	assert.Equal(t, "Business", ref.Class)
+	assert.False(t, ref.Transfer)
+}
+
+func TestCheckTransfer(t *testing.T) {
+	tx := &bankingTransaction{}
+
+	err := tx.parseBankingTransactionField("L[linda]", Config{})
+	require.NoError(t, err)
+
+	ref := tx.CategoryRef()
+	assert.True(t, ref.Transfer)
+	assert.Equal(t, "linda", ref.Account)
+	assert.Empty(t, ref.Path)
+}
+
+func TestTransferAccountWithSlash(t *testing.T) {
+	tx := &bankingTransaction{}
+
+	err := tx.parseBankingTransactionField("L[Savings/Joint]/Class", Config{})
+	require.NoError(t, err)
+	assert.Equal(t, "[Savings/Joint]", tx.Category())
+	assert.Equal(t, "Class", tx.Class())
+
+	err = tx.parseBankingTransactionField("S[Savings/Joint]", Config{})
+	require.NoError(t, err)
+	require.Len(t, tx.Splits(), 1)
+	assert.Equal(t, "[Savings/Joint]", *tx.Splits()[0].Category)
+	assert.Nil(t, tx.Splits()[0].Class)
+
+	ref := tx.CategoryRef()
+	assert.True(t, ref.Transfer)
+	assert.Equal(t, "Savings/Joint", ref.Account)
+	assert.Equal(t, "Class", ref.Class)
+}
+
 func Test5LineAddress(t *testing.T) {
 	tx := &bankingTransaction{}
 
@@ -100,17 +148,115 @@ func TestSplits(t *testing.T) {
 
 	assert.Equal(t, "cat1", *tx.Splits()[0].Category)
 	assert.Equal(t, "memo1", *tx.Splits()[0].Memo)
-	assert.Equal(t, 1299, *tx.Splits()[0].Amount)
+	assert.Equal(t, NewDecimal(1299, 2), *tx.Splits()[0].Amount)
 
 	assert.Nil(t, tx.Splits()[1].Category)
 	assert.Nil(t, tx.Splits()[1].Memo)
-	assert.Equal(t, 399, *tx.Splits()[1].Amount)
+	assert.Equal(t, NewDecimal(399, 2), *tx.Splits()[1].Amount)
 
 	assert.Nil(t, tx.Splits()[2].Category)
 	assert.Equal(t, "memo3", *tx.Splits()[2].Memo)
 	assert.Nil(t, tx.Splits()[2].Amount)
 }
 
+func TestSplitPercent(t *testing.T) {
+	tx := &bankingTransaction{}
+
+	lines := []string{
+		"SFood",
+		"$-75.00",
+		"%75.00%",
+		"SDrink",
+		"$-25.00",
+		"%25",
+		"%10",
+	}
+
+	for _, l := range lines {
+		err := tx.parseBankingTransactionField(l, Config{})
+		require.NoError(t, err)
+	}
+	require.Equal(t, 3, len(tx.Splits()))
+
+	assert.Equal(t, dec("75.00"), *tx.Splits()[0].Percent)
+	assert.Equal(t, dec("25"), *tx.Splits()[1].Percent)
+	assert.Equal(t, dec("-25.00"), *tx.Splits()[1].Amount)
+	assert.Nil(t, tx.Splits()[2].Amount)
+	assert.Equal(t, dec("10"), *tx.Splits()[2].Percent)
+
+	err := tx.parseBankingTransactionField("%abc", Config{})
+	assert.Error(t, err)
+}
+
+func TestCheckSplits(t *testing.T) {
+	tx := &bankingTransaction{}
+	tx.amount = dec("-12.99")
+	assert.NoError(t, CheckSplits(tx))
+
+	tx.splits = []Split{
+		{Category: strptr("Food"), Amount: amtptr(-1000)},
+		{Category: strptr("Drink"), Amount: amtptr(-299)},
+	}
+	assert.NoError(t, CheckSplits(tx))
+
+	tx.splits[1].Amount = amtptr(-199)
+	err := CheckSplits(tx)
+	require.Error(t, err)
+
+	sumErr, ok := err.(*SplitSumError)
+	require.True(t, ok)
+	assert.Equal(t, dec("-12.99"), sumErr.Total)
+	assert.Equal(t, dec("-11.99"), sumErr.Sum)
+
+	// Splits without amounts count as zero
+	tx.splits[1].Amount = nil
+	tx.amount = dec("-10.00")
+	assert.NoError(t, CheckSplits(tx))
+}
+
+func TestBalanceSplits(t *testing.T) {
+	tx := &bankingTransaction{}
+	tx.amount = dec("-12.99")
+	tx.splits = []Split{
+		{Category: strptr("Food"), Amount: amtptr(-1000)},
+	}
+
+	require.NoError(t, tx.balanceSplits())
+	require.Len(t, tx.splits, 2)
+	assert.Nil(t, tx.splits[1].Category)
+	assert.Equal(t, dec("-2.99"), *tx.splits[1].Amount)
+	assert.NoError(t, CheckSplits(tx))
+
+	// Balanced transactions are left alone
+	require.NoError(t, tx.balanceSplits())
+	assert.Len(t, tx.splits, 2)
+
+	max := NewDecimal(math.MaxInt64, 2)
+	tx.amount = dec("-1.00")
+	tx.splits = []Split{{Amount: &max}}
+	assert.Error(t, tx.balanceSplits())
+}
+
+func TestSplitClass(t *testing.T) {
+	tx := &bankingTransaction{}
+
+	for _, l := range []string{"SFood/Business", "SFood"} {
+		err := tx.parseBankingTransactionField(l, Config{})
+		require.NoError(t, err)
+	}
+	require.Equal(t, 2, len(tx.Splits()))
+
+	assert.Equal(t, "Food", *tx.Splits()[0].Category)
+	assert.Equal(t, "Business", *tx.Splits()[0].Class)
+
+	assert.Equal(t, "Food", *tx.Splits()[1].Category)
+	assert.Nil(t, tx.Splits()[1].Class)
+
+	assert.Equal(t, &CategoryRef{Path: []string{"Food"}, Class: "Business"},
+		tx.Splits()[0].CategoryRef())
+	assert.Nil(t, Split{}.CategoryRef())
+}
+
 func TestTransactionField(t *testing.T) {
 	tx := &bankingTransaction{}
 	const memo = "memo"
@@ -0,0 +1,132 @@
+//   Copyright 2018 Duncan Jones
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package qif
+
+import "github.com/pkg/errors"
+
+// A Category is an entry in a category list (i.e. the Cat section type).
+type Category interface {
+
+	// Name of the category. Subcategories are separated from their parent
+	// with a colon (e.g. "Auto:Fuel").
+	Name() string
+
+	// Description of the category.
+	Description() string
+
+	// TaxRelated is true if the category is marked as tax related.
+	TaxRelated() bool
+
+	// Income is true if the category is an income category.
+	Income() bool
+
+	// Expense is true if the category is an expense category. Quicken treats
+	// categories as expenses unless otherwise specified.
+	Expense() bool
+
+	// TaxSchedule contains the tax schedule information associated with the
+	// category.
+	TaxSchedule() string
+
+	// Budget contains zero or more budget amounts, with the number of decimal
+	// places used by Config.Currency. Quicken writes one amount per budget
+	// period (usually a month).
+	Budget() []Decimal
+
+	// RawRecord gives access to the original data of the category.
+	RawRecord
+}
+
+type category struct {
+	rawData
+	name        string
+	description string
+	taxRelated  bool
+	income      bool
+	expense     bool
+	taxSchedule string
+	budget      []Decimal
+}
+
+func (c *category) Name() string {
+	return c.name
+}
+
+func (c *category) Description() string {
+	return c.description
+}
+
+func (c *category) TaxRelated() bool {
+	return c.taxRelated
+}
+
+func (c *category) Income() bool {
+	return c.income
+}
+
+func (c *category) Expense() bool {
+	return c.expense || !c.income
+}
+
+func (c *category) TaxSchedule() string {
+	return c.taxSchedule
+}
+
+func (c *category) Budget() []Decimal {
+	return c.budget
+}
+
+func (c *category) parseCategoryField(line string, config Config) error {
+	if line == "" {
+		return errors.New("line is empty")
+	}
+
+	switch line[0] {
+	case 'N':
+		c.name = line[1:]
+		return nil
+
+	case 'D':
+		c.description = line[1:]
+		return nil
+
+	case 'T':
+		c.taxRelated = true
+		return nil
+
+	case 'I':
+		c.income = true
+		return nil
+
+	case 'E':
+		c.expense = true
+		return nil
+
+	case 'R':
+		c.taxSchedule = line[1:]
+		return nil
+
+	case 'B':
+		amt, err := parseAmount(line[1:], config.Currency, config)
+		if err != nil {
+			return errors.Wrap(err, "failed to parse budget amount")
+		}
+		c.budget = append(c.budget, amt)
+		return nil
+
+	default:
+		return UnsupportedFieldError{Line: line}
+	}
+}
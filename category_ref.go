@@ -0,0 +1,96 @@
+//   Copyright 2018 Duncan Jones
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package qif
+
+import "strings"
+
+// A CategoryRef is a parsed reference to a category or transfer account, as
+// found in the L field of a transaction or the S field of a split. Quicken
+// writes these in the forms "Category:Subcategory/Class" and
+// "[Account]/Class".
+type CategoryRef struct {
+
+	// Path contains the category followed by any subcategories. For instance,
+	// "Auto:Fuel" has the path ["Auto", "Fuel"]. It is empty for transfers.
+	Path []string
+
+	// Transfer is true if the reference names an account rather than a
+	// category, indicating a transfer between accounts.
+	Transfer bool
+
+	// Account is the name of the account involved in a transfer. It is empty
+	// unless Transfer is true.
+	Account string
+
+	// Class associated with the reference, if any.
+	Class string
+}
+
+// ParseCategoryRef parses a category reference of the form
+// "Category:Subcategory/Class" or "[Account]/Class". The class is optional.
+func ParseCategoryRef(s string) CategoryRef {
+	var ref CategoryRef
+
+	var name string
+	name, ref.Class = splitCategory(s)
+
+	if strings.HasPrefix(name, "[") && strings.HasSuffix(name, "]") {
+		ref.Transfer = true
+		ref.Account = name[1 : len(name)-1]
+		return ref
+	}
+
+	if name != "" {
+		ref.Path = strings.Split(name, ":")
+	}
+
+	return ref
+}
+
+// Name returns the category name, with subcategories separated by colons. For
+// transfers, the account name is returned in square brackets.
+func (c CategoryRef) Name() string {
+	if c.Transfer {
+		return "[" + c.Account + "]"
+	}
+
+	return strings.Join(c.Path, ":")
+}
+
+// String returns the reference in QIF form, including any class.
+func (c CategoryRef) String() string {
+	return joinCategory(c.Name(), c.Class)
+}
+
+// Under returns true if the reference is to the named category or to one of
+// its subcategories. Transfers are never under a category.
+func (c CategoryRef) Under(category string) bool {
+	if c.Transfer || category == "" {
+		return false
+	}
+
+	parent := strings.Split(category, ":")
+	if len(parent) > len(c.Path) {
+		return false
+	}
+
+	for i := range parent {
+		if parent[i] != c.Path[i] {
+			return false
+		}
+	}
+
+	return true
+}
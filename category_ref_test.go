@@ -0,0 +1,56 @@
+//   Copyright 2018 Duncan Jones
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package qif
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParseCategoryRef(t *testing.T) {
+	vectors := map[string]CategoryRef{
+		"":                   {},
+		"Entertain":          {Path: []string{"Entertain"}},
+		"Auto:Fuel":          {Path: []string{"Auto", "Fuel"}},
+		"Auto:Fuel/Business": {Path: []string{"Auto", "Fuel"}, Class: "Business"},
+		"/Business":          {Class: "Business"},
+		"[linda]":            {Transfer: true, Account: "linda"},
+		"[linda]/Business":   {Transfer: true, Account: "linda", Class: "Business"},
+		"[a/b]/Business":     {Transfer: true, Account: "a/b", Class: "Business"},
+	}
+
+	for k, v := range vectors {
+		ref := ParseCategoryRef(k)
+		assert.Equalf(t, v, ref, "failed for input %s", k)
+		assert.Equalf(t, k, ref.String(), "failed for input %s", k)
+	}
+}
+
+func TestCategoryRefName(t *testing.T) {
+	assert.Equal(t, "Auto:Fuel", ParseCategoryRef("Auto:Fuel/Business").Name())
+	assert.Equal(t, "[linda]", ParseCategoryRef("[linda]/Business").Name())
+}
+
+func TestCategoryRefUnder(t *testing.T) {
+	ref := ParseCategoryRef("Auto:Fuel/Business")
+
+	assert.True(t, ref.Under("Auto"))
+	assert.True(t, ref.Under("Auto:Fuel"))
+	assert.False(t, ref.Under("Auto:Fuel:Diesel"))
+	assert.False(t, ref.Under("Aut"))
+	assert.False(t, ref.Under(""))
+
+	assert.False(t, ParseCategoryRef("[Auto]").Under("Auto"))
+}
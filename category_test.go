@@ -0,0 +1,87 @@
+//   Copyright 2018 Duncan Jones
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package qif
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestCategoryFields(t *testing.T) {
+	c := &category{}
+
+	lines := []string{
+		"NAuto:Fuel",
+		"DPetrol and diesel",
+		"T",
+		"E",
+		"R1234",
+		"B100.00",
+		"B120.50",
+	}
+
+	for _, l := range lines {
+		err := c.parseCategoryField(l, Config{})
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, "Auto:Fuel", c.Name())
+	assert.Equal(t, "Petrol and diesel", c.Description())
+	assert.True(t, c.TaxRelated())
+	assert.False(t, c.Income())
+	assert.True(t, c.Expense())
+	assert.Equal(t, "1234", c.TaxSchedule())
+	assert.Equal(t, []Decimal{dec("100.00"), dec("120.50")}, c.Budget())
+}
+
+func TestIncomeCategory(t *testing.T) {
+	c := &category{}
+	err := c.parseCategoryField("I", Config{})
+	require.NoError(t, err)
+
+	assert.True(t, c.Income())
+	assert.False(t, c.Expense())
+	assert.False(t, c.TaxRelated())
+}
+
+func TestDefaultCategoryType(t *testing.T) {
+	c := &category{}
+	err := c.parseCategoryField("NMisc", Config{})
+	require.NoError(t, err)
+
+	assert.False(t, c.Income())
+	assert.True(t, c.Expense())
+}
+
+func TestBadCategoryBudget(t *testing.T) {
+	c := &category{}
+	err := c.parseCategoryField("Babc", Config{})
+	assert.Error(t, err)
+}
+
+func TestBadCategoryLine(t *testing.T) {
+	c := &category{}
+	err := c.parseCategoryField("Z1234", Config{})
+
+	_, ok := err.(UnsupportedFieldError)
+	assert.True(t, ok)
+}
+
+func TestCategoryEmptyLine(t *testing.T) {
+	c := &category{}
+	err := c.parseCategoryField("", Config{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,65 @@
+//   Copyright 2018 Duncan Jones
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package qif
+
+import "github.com/pkg/errors"
+
+// A Class is an entry in a class list (i.e. the Class section type). Classes
+// provide a second way to group transactions, independent of category.
+type Class interface {
+
+	// Name of the class. Subclasses are separated from their parent with a
+	// colon.
+	Name() string
+
+	// Description of the class.
+	Description() string
+
+	// RawRecord gives access to the original data of the class.
+	RawRecord
+}
+
+type class struct {
+	rawData
+	name        string
+	description string
+}
+
+func (c *class) Name() string {
+	return c.name
+}
+
+func (c *class) Description() string {
+	return c.description
+}
+
+func (c *class) parseClassField(line string, config Config) error {
+	if line == "" {
+		return errors.New("line is empty")
+	}
+
+	switch line[0] {
+	case 'N':
+		c.name = line[1:]
+		return nil
+
+	case 'D':
+		c.description = line[1:]
+		return nil
+
+	default:
+		return UnsupportedFieldError{Line: line}
+	}
+}
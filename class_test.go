@@ -0,0 +1,47 @@
+//   Copyright 2018 Duncan Jones
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package qif
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestClassFields(t *testing.T) {
+	c := &class{}
+
+	for _, l := range []string{"NBusiness", "DWork expenses"} {
+		err := c.parseClassField(l, Config{})
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, "Business", c.Name())
+	assert.Equal(t, "Work expenses", c.Description())
+}
+
+func TestBadClassLine(t *testing.T) {
+	c := &class{}
+	err := c.parseClassField("Z1234", Config{})
+
+	_, ok := err.(UnsupportedFieldError)
+	assert.True(t, ok)
+}
+
+func TestClassEmptyLine(t *testing.T) {
+	c := &class{}
+	err := c.parseClassField("", Config{})
+	assert.Error(t, err)
+}
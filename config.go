@@ -14,20 +14,155 @@
 
 package qif
 
+import "time"
+
+// defaultCenturyPivot is used when Config.CenturyPivot is zero. It matches
+// the behaviour of Go's time package.
+const defaultCenturyPivot = 1969
+
 // Config defines the configuration of the reader.
 type Config struct {
 
 	// DayFirst specifies whether to interpret dates as mm/dd or dd/mm.
 	DayFirst bool
+
+	// CenturyPivot controls the expansion of two digit years, which are placed
+	// in the hundred years starting at CenturyPivot. For instance, a value of
+	// 1950 means "49" is read as 2049 and "50" as 1950. If zero, 1969 is used.
+	// Years preceded by an apostrophe (e.g. '05) are always after 1999, as
+	// Quicken uses this notation for years 2000 onwards.
+	CenturyPivot int
+
+	// ReferenceDate is used to expand single digit years preceded by an
+	// apostrophe (e.g. "1/2'5"), which are placed in the decade containing the
+	// reference date. If zero or before 2000, the 2000s are used, as
+	// apostrophe years are always after 1999. Quicken's space-padded form
+	// (e.g. "1/2' 5") is always read as 2005.
+	ReferenceDate time.Time
+
+	// MonthNames contains additional month names to recognise when parsing
+	// dates such as "15 März 2024" or "15-mars-2024". Tables are provided for
+	// several languages (e.g. GermanMonths). English names are always
+	// recognised.
+	MonthNames MonthNames
+
+	// DateLayouts contains additional layouts to try when parsing dates, using
+	// the format of the time package. These are tried before the built-in
+	// formats.
+	DateLayouts []string
+
+	// ParseDate, if set, replaces the built-in date parsing. It receives the
+	// date string from the input data.
+	ParseDate func(s string) (time.Time, error)
+
+	// DecimalSeparator is the character separating whole and fractional parts
+	// of amounts and numbers. If zero, '.' is used.
+	DecimalSeparator rune
+
+	// GroupSeparator is the character separating groups of thousands in
+	// amounts and numbers. Groups must contain three digits. If zero, ',' is
+	// used, unless DecimalSeparator is ',', in which case '.' is used.
+	GroupSeparator rune
+
+	// Currency is the ISO 4217 code of the currency used for amounts (e.g.
+	// "JPY"), which determines the number of decimal places. If empty, amounts
+	// have two decimal places.
+	Currency string
+
+	// AccountCurrencies maps account names to ISO 4217 currency codes, for
+	// accounts that do not use Currency. It applies to transactions following
+	// an !Account record with a matching name.
+	AccountCurrencies map[string]string
+
+	// BalanceSplits specifies whether the reader should add a split to
+	// transactions whose split amounts do not add up to the total amount. The
+	// new split has no category and holds the remainder. See CheckSplits.
+	BalanceSplits bool
+
+	// Encoding is the character encoding of the input, which is converted to
+	// UTF-8 before parsing. If zero (AutoEncoding), the encoding is detected
+	// as described for AutoEncoding. The writer uses the same encoding for its
+	// output, writing a byte order mark for UTF-16.
+	Encoding Encoding
+
+	// MaxLineLength is the maximum length of a line in bytes, excluding the
+	// line ending. Reading fails with ErrLineTooLong if a line is longer. If
+	// zero, 1MiB is used.
+	MaxLineLength int
+
+	// KeepUnknownFields specifies whether fields with codes the reader does
+	// not understand are kept, rather than causing an error. The fields and
+	// the raw lines of each record can be retrieved through RawRecord, and are
+	// written out again by the writer.
+	KeepUnknownFields bool
+
+	// FieldHandlers maps section names and field codes to handlers for fields
+	// the reader does not understand. Use HandleField to add handlers.
+	FieldHandlers map[string]map[byte]FieldHandler
+
+	// Progress, if set, is called by the reader after each record and at the
+	// end of the input. It receives the number of bytes of input consumed and
+	// the number of records read, including any that were skipped.
+	Progress func(bytes int64, records int)
+
+	// DetectDateOrder specifies whether the reader should determine the date
+	// order from the input, by looking for dates that are only valid one way
+	// (e.g. 31/12). The input is scanned before the first record is read, so
+	// inputs that do not implement io.Seeker are buffered in memory. Reading
+	// fails with ErrAmbiguousDateOrder if dates could be read either way and
+	// none decide the matter. DayFirst is used if the input contains no
+	// ambiguous dates. The detected order can be retrieved with
+	// Reader.DayFirst.
+	DetectDateOrder bool
+
+	// SkipBadRecords specifies whether records containing errors are skipped,
+	// rather than causing the reader to fail. The reader resumes at the end of
+	// the bad record (or the next header, if the header itself is bad). Each
+	// problem is recorded and can be retrieved with Reader.Diagnostics.
+	SkipBadRecords bool
 }
 
 // DefaultConfig returns the default configuration used by NewReader:
 //
 //  Config{
-//    DayFirst: false,
+//    DayFirst:          false,
+//    CenturyPivot:      0,
+//    ReferenceDate:     time.Time{},
+//    MonthNames:        nil,
+//    DateLayouts:       nil,
+//    ParseDate:         nil,
+//    DecimalSeparator:  0,
+//    GroupSeparator:    0,
+//    Currency:          "",
+//    AccountCurrencies: nil,
+//    BalanceSplits:     false,
+//    Encoding:          AutoEncoding,
+//    MaxLineLength:     0,
+//    KeepUnknownFields: false,
+//    FieldHandlers:     nil,
+//    Progress:          nil,
+//    DetectDateOrder:   false,
+//    SkipBadRecords:    false,
 //  }
 func DefaultConfig() Config {
 	return Config{
-		DayFirst: false,
+		DayFirst:          false,
+		CenturyPivot:      0,
+		ReferenceDate:     time.Time{},
+		MonthNames:        nil,
+		DateLayouts:       nil,
+		ParseDate:         nil,
+		DecimalSeparator:  0,
+		GroupSeparator:    0,
+		Currency:          "",
+		AccountCurrencies: nil,
+		BalanceSplits:     false,
+		Encoding:          AutoEncoding,
+		MaxLineLength:     0,
+		KeepUnknownFields: false,
+		FieldHandlers:     nil,
+		Progress:          nil,
+		DetectDateOrder:   false,
+		SkipBadRecords:    false,
 	}
 }
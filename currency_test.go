@@ -0,0 +1,53 @@
+//   Copyright 2018 Duncan Jones
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package qif
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestMinorUnits(t *testing.T) {
+	vectors := map[string]int{
+		"":    2,
+		"USD": 2,
+		"eur": 2,
+		"JPY": 0,
+		"KWD": 3,
+		"BHD": 3,
+		"CLF": 4,
+	}
+
+	for k, v := range vectors {
+		digits, err := MinorUnits(k)
+		require.NoErrorf(t, err, "error processing '%s'", k)
+		assert.Equalf(t, v, digits, "error processing '%s'", k)
+	}
+
+	_, err := MinorUnits("XYZ")
+	assert.Error(t, err)
+}
+
+func TestConfigCurrency(t *testing.T) {
+	config := Config{
+		Currency:          "GBP",
+		AccountCurrencies: map[string]string{"Yen Account": "JPY"},
+	}
+
+	assert.Equal(t, "GBP", config.currency(nil))
+	assert.Equal(t, "GBP", config.currency(&account{name: "Checking"}))
+	assert.Equal(t, "JPY", config.currency(&account{name: "Yen Account"}))
+}
@@ -0,0 +1,401 @@
+//   Copyright 2018 Duncan Jones
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package qif
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// MaxScale is the largest number of decimal places a Decimal can hold.
+const MaxScale = 18
+
+// ErrDecimalOverflow is returned when a number is too large to be held in a
+// Decimal. Arithmetic methods panic with this error if their result
+// overflows.
+var ErrDecimalOverflow = errors.New("decimal overflow")
+
+// RoundingMode specifies how Decimal.Round discards digits.
+type RoundingMode int
+
+const (
+	// RoundHalfEven rounds to the nearest value, with ties going to the even
+	// neighbour (banker's rounding).
+	RoundHalfEven RoundingMode = iota
+
+	// RoundHalfUp rounds to the nearest value, with ties going away from
+	// zero.
+	RoundHalfUp
+
+	// RoundHalfDown rounds to the nearest value, with ties going towards
+	// zero.
+	RoundHalfDown
+
+	// RoundUp rounds away from zero.
+	RoundUp
+
+	// RoundDown rounds towards zero, truncating the value.
+	RoundDown
+
+	// RoundCeiling rounds towards positive infinity.
+	RoundCeiling
+
+	// RoundFloor rounds towards negative infinity.
+	RoundFloor
+)
+
+// A Decimal is an exact decimal number, used for amounts, prices and
+// quantities. It holds an integer coefficient and a scale, giving the value
+// coefficient × 10^-scale. For instance, 12.99 has coefficient 1299 and scale
+// 2. The zero value is 0.
+//
+// Decimals with the same value but different scales (e.g. 1.5 and 1.50) are
+// not identical; use Equal or Cmp to compare values.
+type Decimal struct {
+	coef  int64
+	scale int
+}
+
+// NewDecimal returns the Decimal coef × 10^-scale. It panics if scale is
+// negative or greater than MaxScale.
+func NewDecimal(coef int64, scale int) Decimal {
+	checkScale(scale)
+	return Decimal{coef: coef, scale: scale}
+}
+
+// ParseDecimal converts a string such as "-1234.5678" into a Decimal. The
+// scale is the number of digits after the decimal point. Grouping separators
+// and exponents are not accepted.
+func ParseDecimal(s string) (Decimal, error) {
+	digits := strings.TrimLeft(s, "+-")
+	if len(s)-len(digits) > 1 {
+		return Decimal{}, errors.Errorf(`bad decimal string "%s"`, s)
+	}
+
+	integer, fraction := digits, ""
+	if i := strings.IndexByte(digits, '.'); i >= 0 {
+		integer, fraction = digits[:i], digits[i+1:]
+	}
+
+	if integer+fraction == "" || !isDigits(integer) || !isDigits(fraction) {
+		return Decimal{}, errors.Errorf(`bad decimal string "%s"`, s)
+	}
+
+	if len(fraction) > MaxScale {
+		return Decimal{}, errors.Errorf(
+			`decimal string "%s" has more than %d decimal places`, s, MaxScale)
+	}
+
+	coef, err := strconv.ParseInt(s[:len(s)-len(digits)]+integer+fraction, 10,
+		64)
+	if err != nil {
+		return Decimal{}, errors.Wrapf(ErrDecimalOverflow,
+			`bad decimal string "%s"`, s)
+	}
+
+	return Decimal{coef: coef, scale: len(fraction)}, nil
+}
+
+// isDigits reports whether s contains only the digits 0-9.
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Coefficient returns the integer coefficient of d. For amounts, this is the
+// value in minor currency units (e.g. 1299 for 12.99).
+func (d Decimal) Coefficient() int64 {
+	return d.coef
+}
+
+// Scale returns the number of digits after the decimal point.
+func (d Decimal) Scale() int {
+	return d.scale
+}
+
+// Sign returns -1, 0 or 1 depending on whether d is negative, zero or
+// positive.
+func (d Decimal) Sign() int {
+	switch {
+	case d.coef < 0:
+		return -1
+	case d.coef > 0:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// IsZero reports whether d is zero, at any scale.
+func (d Decimal) IsZero() bool {
+	return d.coef == 0
+}
+
+// Neg returns -d. It panics with ErrDecimalOverflow if the result overflows.
+func (d Decimal) Neg() Decimal {
+	if d.coef == math.MinInt64 {
+		panic(ErrDecimalOverflow)
+	}
+
+	return Decimal{coef: -d.coef, scale: d.scale}
+}
+
+// Abs returns the absolute value of d. It panics with ErrDecimalOverflow if
+// the result overflows.
+func (d Decimal) Abs() Decimal {
+	if d.coef < 0 {
+		return d.Neg()
+	}
+
+	return d
+}
+
+// Add returns d + e, using the larger of their scales. It panics with
+// ErrDecimalOverflow if the result overflows.
+func (d Decimal) Add(e Decimal) Decimal {
+	d, e = align(d, e)
+
+	sum := d.coef + e.coef
+	if (e.coef > 0 && sum < d.coef) || (e.coef < 0 && sum > d.coef) {
+		panic(ErrDecimalOverflow)
+	}
+
+	return Decimal{coef: sum, scale: d.scale}
+}
+
+// Sub returns d - e, using the larger of their scales. It panics with
+// ErrDecimalOverflow if the result overflows.
+func (d Decimal) Sub(e Decimal) Decimal {
+	d, e = align(d, e)
+
+	diff := d.coef - e.coef
+	if (e.coef > 0 && diff > d.coef) || (e.coef < 0 && diff < d.coef) {
+		panic(ErrDecimalOverflow)
+	}
+
+	return Decimal{coef: diff, scale: d.scale}
+}
+
+// Mul returns d × e. The scale of the result is the sum of their scales,
+// limited to MaxScale by rounding half to even. It panics with
+// ErrDecimalOverflow if the result overflows.
+func (d Decimal) Mul(e Decimal) Decimal {
+	product := new(big.Int).Mul(big.NewInt(d.coef), big.NewInt(e.coef))
+	scale := d.scale + e.scale
+
+	if scale > MaxScale {
+		product = roundBig(product, scale-MaxScale, RoundHalfEven)
+		scale = MaxScale
+	}
+
+	if !product.IsInt64() {
+		panic(ErrDecimalOverflow)
+	}
+
+	return Decimal{coef: product.Int64(), scale: scale}
+}
+
+// Cmp compares the values of d and e, returning -1 if d < e, 0 if d == e and
+// 1 if d > e.
+func (d Decimal) Cmp(e Decimal) int {
+	return d.big(MaxScale).Cmp(e.big(MaxScale))
+}
+
+// Equal reports whether d and e have the same value, regardless of scale.
+func (d Decimal) Equal(e Decimal) bool {
+	return d.Cmp(e) == 0
+}
+
+// Round returns d with the given number of decimal places, discarding digits
+// according to mode. If scale is greater than the scale of d, trailing zeros
+// are added. It panics if scale is negative or greater than MaxScale, or with
+// ErrDecimalOverflow if the result overflows.
+func (d Decimal) Round(scale int, mode RoundingMode) Decimal {
+	checkScale(scale)
+
+	if scale >= d.scale {
+		return d.rescale(scale)
+	}
+
+	coef := roundBig(big.NewInt(d.coef), d.scale-scale, mode)
+	return Decimal{coef: coef.Int64(), scale: scale}
+}
+
+// Float64 returns the nearest float64 value to d.
+func (d Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+
+// String returns d with all its decimal places (e.g. "-12.50").
+func (d Decimal) String() string {
+	digits := new(big.Int).Abs(big.NewInt(d.coef)).String()
+	if len(digits) <= d.scale {
+		digits = strings.Repeat("0", d.scale-len(digits)+1) + digits
+	}
+
+	sign := ""
+	if d.coef < 0 {
+		sign = "-"
+	}
+
+	if d.scale == 0 {
+		return sign + digits
+	}
+
+	point := len(digits) - d.scale
+	return sign + digits[:point] + "." + digits[point:]
+}
+
+// Format implements fmt.Formatter. The verbs %v and %s print the same as
+// String, while %f additionally accepts a precision (e.g. %.2f), rounding
+// half to even. The width and the '-', '+' and '0' flags are supported.
+func (d Decimal) Format(f fmt.State, verb rune) {
+	switch verb {
+	case 'v', 's', 'f':
+	default:
+		fmt.Fprintf(f, "%%!%c(qif.Decimal=%s)", verb, d.String())
+		return
+	}
+
+	if prec, ok := f.Precision(); ok && verb == 'f' {
+		d = d.Round(prec, RoundHalfEven)
+	}
+
+	s := d.String()
+	sign := ""
+	if d.coef < 0 {
+		sign, s = "-", s[1:]
+	} else if f.Flag('+') {
+		sign = "+"
+	}
+
+	width, _ := f.Width()
+	pad := width - len(sign) - len(s)
+
+	switch {
+	case pad <= 0:
+		s = sign + s
+	case f.Flag('-'):
+		s = sign + s + strings.Repeat(" ", pad)
+	case f.Flag('0'):
+		s = sign + strings.Repeat("0", pad) + s
+	default:
+		s = strings.Repeat(" ", pad) + sign + s
+	}
+
+	fmt.Fprint(f, s)
+}
+
+// checkOverflow calls f, returning ErrDecimalOverflow as an error if f panics
+// with it.
+func checkOverflow(f func() Decimal) (d Decimal, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if r != ErrDecimalOverflow {
+				panic(r)
+			}
+			err = ErrDecimalOverflow
+		}
+	}()
+
+	return f(), nil
+}
+
+// rescale returns d with a larger scale. It panics with ErrDecimalOverflow if
+// the result overflows.
+func (d Decimal) rescale(scale int) Decimal {
+	coef := d.big(scale)
+	if !coef.IsInt64() {
+		panic(ErrDecimalOverflow)
+	}
+
+	return Decimal{coef: coef.Int64(), scale: scale}
+}
+
+// big returns the coefficient of d at the given scale, which must not be less
+// than the scale of d.
+func (d Decimal) big(scale int) *big.Int {
+	coef := big.NewInt(d.coef)
+	return coef.Mul(coef, pow10(scale-d.scale))
+}
+
+// align returns d and e rescaled to the larger of their scales.
+func align(d, e Decimal) (Decimal, Decimal) {
+	if d.scale < e.scale {
+		return d.rescale(e.scale), e
+	}
+
+	return d, e.rescale(d.scale)
+}
+
+// roundBig divides coef by 10^digits, rounding according to mode.
+func roundBig(coef *big.Int, digits int, mode RoundingMode) *big.Int {
+	divisor := pow10(digits)
+	q, r := new(big.Int).QuoRem(coef, divisor, new(big.Int))
+	if r.Sign() == 0 {
+		return q
+	}
+
+	// Compare twice the remainder to the divisor to find ties
+	twice := new(big.Int).Abs(r)
+	half := twice.Mul(twice, big.NewInt(2)).Cmp(divisor)
+
+	var away bool
+	switch mode {
+	case RoundHalfEven:
+		away = half > 0 || (half == 0 && q.Bit(0) == 1)
+	case RoundHalfUp:
+		away = half >= 0
+	case RoundHalfDown:
+		away = half > 0
+	case RoundUp:
+		away = true
+	case RoundDown:
+		away = false
+	case RoundCeiling:
+		away = coef.Sign() > 0
+	case RoundFloor:
+		away = coef.Sign() < 0
+	}
+
+	if away {
+		q.Add(q, big.NewInt(int64(coef.Sign())))
+	}
+
+	return q
+}
+
+// pow10 returns 10^n.
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// checkScale panics if scale is outside the range supported by Decimal.
+func checkScale(scale int) {
+	if scale < 0 || scale > MaxScale {
+		panic(fmt.Sprintf("qif: decimal scale %d out of range", scale))
+	}
+}
@@ -0,0 +1,192 @@
+//   Copyright 2018 Duncan Jones
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package qif
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"math"
+	"testing"
+)
+
+// dec parses a decimal string, panicking on failure.
+func dec(s string) Decimal {
+	d, err := ParseDecimal(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// decptr returns a pointer to a parsed decimal string.
+func decptr(s string) *Decimal {
+	d := dec(s)
+	return &d
+}
+
+func TestParseDecimal(t *testing.T) {
+	vectors := map[string]Decimal{
+		"0":        NewDecimal(0, 0),
+		"12.99":    NewDecimal(1299, 2),
+		"-12.50":   NewDecimal(-1250, 2),
+		"+7":       NewDecimal(7, 0),
+		".5":       NewDecimal(5, 1),
+		"12.":      NewDecimal(12, 0),
+		"-0.0001":  NewDecimal(-1, 4),
+		"101.125":  NewDecimal(101125, 3),
+		"12.3456":  NewDecimal(123456, 4),
+		"00012.30": NewDecimal(1230, 2),
+	}
+
+	for k, v := range vectors {
+		d, err := ParseDecimal(k)
+		assert.NoErrorf(t, err, "error processing '%s'", k)
+		assert.Equalf(t, v, d, "error processing '%s'", k)
+	}
+
+	badVectors := []string{
+		"",
+		"-",
+		".",
+		"+-1",
+		"1.2.3",
+		"1,000",
+		"1e5",
+		"12x",
+		" 12",
+		"0.1234567890123456789",
+	}
+
+	for _, v := range badVectors {
+		_, err := ParseDecimal(v)
+		assert.Errorf(t, err, "error processing '%s'", v)
+	}
+
+	_, err := ParseDecimal("92233720368547758.08")
+	require.Error(t, err)
+	assert.Equal(t, ErrDecimalOverflow, errors.Cause(err))
+}
+
+func TestDecimalString(t *testing.T) {
+	vectors := map[string]Decimal{
+		"0":                    {},
+		"0.00":                 NewDecimal(0, 2),
+		"0.05":                 NewDecimal(5, 2),
+		"-0.05":                NewDecimal(-5, 2),
+		"12.99":                NewDecimal(1299, 2),
+		"-1000.00":             NewDecimal(-100000, 2),
+		"-1299":                NewDecimal(-1299, 0),
+		"0.005":                NewDecimal(5, 3),
+		"-9223372036854775808": NewDecimal(math.MinInt64, 0),
+	}
+
+	for k, v := range vectors {
+		assert.Equal(t, k, v.String())
+	}
+}
+
+func TestDecimalArithmetic(t *testing.T) {
+	assert.Equal(t, dec("13.245"), dec("12.99").Add(dec("0.255")))
+	assert.Equal(t, dec("-0.01"), dec("12.99").Sub(dec("13")))
+	assert.Equal(t, dec("1021.88026000"), dec("101.125").Mul(dec("10.10512")))
+	assert.Equal(t, dec("12.99"), dec("-12.99").Neg())
+	assert.Equal(t, dec("12.99"), dec("-12.99").Abs())
+	assert.Equal(t, dec("12.99"), dec("12.99").Abs())
+
+	assert.Equal(t, -1, dec("-1").Sign())
+	assert.Equal(t, 0, dec("0.00").Sign())
+	assert.Equal(t, 1, dec("0.01").Sign())
+	assert.True(t, dec("0.00").IsZero())
+	assert.Equal(t, 12.99, dec("12.99").Float64())
+}
+
+func TestDecimalOverflow(t *testing.T) {
+	max := NewDecimal(math.MaxInt64, 0)
+	min := NewDecimal(math.MinInt64, 0)
+
+	assert.PanicsWithValue(t, ErrDecimalOverflow, func() {
+		max.Add(dec("1"))
+	})
+	assert.PanicsWithValue(t, ErrDecimalOverflow, func() {
+		min.Sub(dec("1"))
+	})
+	assert.PanicsWithValue(t, ErrDecimalOverflow, func() {
+		max.Mul(dec("2"))
+	})
+	assert.PanicsWithValue(t, ErrDecimalOverflow, func() {
+		min.Neg()
+	})
+	assert.PanicsWithValue(t, ErrDecimalOverflow, func() {
+		max.Round(1, RoundHalfEven)
+	})
+
+	// Aligning scales can also overflow
+	assert.PanicsWithValue(t, ErrDecimalOverflow, func() {
+		max.Add(dec("0.1"))
+	})
+
+	// Results that only just fit are fine
+	assert.Equal(t, max, max.Sub(dec("1")).Add(dec("1")))
+	assert.Equal(t, 1, max.Cmp(dec("0.1")))
+}
+
+func TestDecimalCompare(t *testing.T) {
+	assert.True(t, dec("1.5").Equal(dec("1.50")))
+	assert.NotEqual(t, dec("1.5"), dec("1.50"))
+	assert.Equal(t, 0, dec("1.5").Cmp(dec("1.500")))
+	assert.Equal(t, -1, dec("-2").Cmp(dec("-1.99")))
+	assert.Equal(t, 1, dec("0.001").Cmp(dec("0")))
+}
+
+func TestDecimalRound(t *testing.T) {
+	modes := []RoundingMode{RoundHalfEven, RoundHalfUp, RoundHalfDown,
+		RoundUp, RoundDown, RoundCeiling, RoundFloor}
+
+	vectors := map[string][]string{
+		"2.5":   {"2", "3", "2", "3", "2", "3", "2"},
+		"3.5":   {"4", "4", "3", "4", "3", "4", "3"},
+		"-2.5":  {"-2", "-3", "-2", "-3", "-2", "-2", "-3"},
+		"2.51":  {"3", "3", "3", "3", "2", "3", "2"},
+		"-2.49": {"-2", "-2", "-2", "-3", "-2", "-2", "-3"},
+		"2":     {"2", "2", "2", "2", "2", "2", "2"},
+	}
+
+	for k, v := range vectors {
+		for i, mode := range modes {
+			assert.Equalf(t, dec(v[i]), dec(k).Round(0, mode),
+				"error processing '%s' with mode %d", k, mode)
+		}
+	}
+
+	assert.Equal(t, dec("12.35"), dec("12.345").Round(2, RoundHalfUp))
+	assert.Equal(t, dec("12.3400"), dec("12.34").Round(4, RoundDown))
+	assert.Panics(t, func() { dec("1").Round(-1, RoundDown) })
+}
+
+func TestDecimalFormat(t *testing.T) {
+	d := dec("-12.345")
+
+	assert.Equal(t, "-12.345", fmt.Sprint(d))
+	assert.Equal(t, "-12.345", fmt.Sprintf("%s", d))
+	assert.Equal(t, "-12.34", fmt.Sprintf("%.2f", d))
+	assert.Equal(t, "-12", fmt.Sprintf("%.0f", d))
+	assert.Equal(t, "   -12.345", fmt.Sprintf("%10v", d))
+	assert.Equal(t, "-12.345   |", fmt.Sprintf("%-10v|", d))
+	assert.Equal(t, "-00012.34", fmt.Sprintf("%09.2f", d))
+	assert.Equal(t, "+1.5", fmt.Sprintf("%+v", dec("1.5")))
+	assert.Equal(t, "%!d(qif.Decimal=-12.345)", fmt.Sprintf("%d", d))
+}
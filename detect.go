@@ -0,0 +1,248 @@
+//   Copyright 2018 Duncan Jones
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package qif
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// numericDate matches the day and month parts of a numeric date, in either
+// order.
+var numericDate = regexp.MustCompile(`^\s*(\d{1,2})\s*[/.\-]\s*(\d{1,2})\b`)
+
+// detectDateOrder scans the input held in r.src to determine whether dates
+// are written day first, then prepares the reader to parse the input from the
+// beginning. Inputs that implement io.Seeker are rewound after scanning;
+// other inputs are buffered in memory. If detection fails, the error is kept
+// in r.detectErr so that later reads fail in the same way. If r.ctx is
+// cancelled, r.src is restored so that detection is tried again by the next
+// read.
+func (r *reader) detectDateOrder() error {
+	err := r.scanInput()
+	if r.src == nil {
+		r.detectErr = err
+	}
+
+	return err
+}
+
+// scanInput performs the work of detectDateOrder.
+func (r *reader) scanInput() error {
+	src := r.src
+	r.src = nil
+
+	var (
+		prescan io.Reader
+		start   int64
+		err     error
+	)
+
+	seeker, canSeek := src.(io.ReadSeeker)
+	if canSeek {
+		start, err = seeker.Seek(0, io.SeekCurrent)
+		canSeek = err == nil
+	}
+
+	if canSeek {
+		prescan = seeker
+	} else {
+		data, err := io.ReadAll(src)
+		if err != nil {
+			r.setInput(bytes.NewReader(nil))
+			return &ParseError{Err: err}
+		}
+
+		src = bytes.NewReader(data)
+		prescan = bytes.NewReader(data)
+	}
+
+	decoded, enc, bomLen := decodeInput(prescan, r.config.Encoding)
+	dayFirst, found, detectErr := scanDateOrder(r.ctx, decoded, enc,
+		int64(bomLen), r.config.MaxLineLength)
+
+	if canSeek {
+		_, err = seeker.Seek(start, io.SeekStart)
+		if err != nil {
+			r.setInput(bytes.NewReader(nil))
+			return &ParseError{Err: err}
+		}
+	}
+
+	if detectErr != nil && r.ctx != nil && r.ctx.Err() != nil {
+		// Leave the input to be scanned again
+		r.src = src
+		return detectErr
+	}
+
+	r.setInput(src)
+
+	if detectErr != nil {
+		return detectErr
+	}
+
+	if found {
+		r.config.DayFirst = dayFirst
+	}
+
+	return nil
+}
+
+// scanDateOrder reads all the dates in the input and determines whether they
+// are written day first. Dates where either the first or second number is
+// greater than 12 can only be read one way. found is false if no such dates
+// exist. An error is returned if the dates contradict each other, or if there
+// are no decisive dates but some could be read either way. The input is
+// decoded as described for decodeLine, and offset is the position in the
+// input at which in starts. If ctx is not nil, scanning stops with an error
+// once it is cancelled.
+func scanDateOrder(ctx context.Context, in io.Reader, enc Encoding,
+	offset int64, maxLength int) (dayFirst, found bool, err error) {
+	var (
+		header                       string
+		dayFirstLine, monthFirstLine *ParseError
+		ambiguousLine                *ParseError
+		lineNum                      int
+		lineOffset                   int64
+	)
+
+	scanner := newLineScanner(in, maxLength)
+	scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		advance, token, err := splitLines(data, atEOF,
+			maxLineLength(maxLength))
+		offset += rawLength(data[:advance], enc)
+		return advance, token, err
+	})
+
+	for {
+		lineOffset = offset
+		if ctx != nil && ctx.Err() != nil {
+			return false, false, &ParseError{Line: lineNum + 1,
+				Offset: lineOffset, Err: ctx.Err()}
+		}
+
+		if !scanner.Scan() {
+			break
+		}
+
+		lineNum++
+		line := normaliseLine(decodeLine(scanner.Bytes(), enc))
+
+		if strings.HasPrefix(line, "!") {
+			header = line
+			continue
+		}
+
+		date, ok := dateValue(header, line)
+		if !ok {
+			continue
+		}
+
+		m := numericDate.FindStringSubmatch(date)
+		if m == nil {
+			continue
+		}
+
+		first, _ := strconv.Atoi(m[1])
+		second, _ := strconv.Atoi(m[2])
+		pe := &ParseError{Line: lineNum, Offset: lineOffset, Text: line}
+		if line != "" && header != pricesHeader {
+			pe.Field = line[0]
+		}
+
+		switch {
+		case first > 12 && second > 12:
+			// Invalid either way; leave this for the parser to report
+		case first > 12:
+			if dayFirstLine == nil {
+				dayFirstLine = pe
+			}
+		case second > 12:
+			if monthFirstLine == nil {
+				monthFirstLine = pe
+			}
+		case first != second:
+			if ambiguousLine == nil {
+				ambiguousLine = pe
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		if err == ErrLineTooLong {
+			lineNum++
+		}
+		return false, false, &ParseError{Line: lineNum, Offset: lineOffset,
+			Err: err}
+	}
+
+	switch {
+	case dayFirstLine != nil && monthFirstLine != nil:
+		// Report whichever contradicting date appears later
+		pe := dayFirstLine
+		if monthFirstLine.Line > pe.Line {
+			pe = monthFirstLine
+		}
+		pe.Err = ErrInconsistentDateOrder
+		return false, false, pe
+
+	case dayFirstLine != nil:
+		return true, true, nil
+
+	case monthFirstLine != nil:
+		return false, true, nil
+
+	case ambiguousLine != nil:
+		ambiguousLine.Err = ErrAmbiguousDateOrder
+		return false, false, ambiguousLine
+
+	default:
+		return false, false, nil
+	}
+}
+
+// dateValue returns the date contained in a line, given the header of the
+// section it belongs to. ok is false if the line does not contain a date.
+func dateValue(header, line string) (date string, ok bool) {
+	if line == "" {
+		return "", false
+	}
+
+	if _, isRegister := registerHeaders[header]; isRegister {
+		return line[1:], line[0] == 'D'
+	}
+
+	switch header {
+	case memorizedHeader:
+		return line[1:], line[0] == 'D' || line[0] == '1'
+
+	case accountHeader:
+		return line[1:], line[0] == '/'
+
+	case pricesHeader:
+		values, err := csv.NewReader(strings.NewReader(line)).Read()
+		if err != nil || len(values) != 3 {
+			return "", false
+		}
+		return values[2], true
+	}
+
+	return "", false
+}
@@ -0,0 +1,189 @@
+//   Copyright 2018 Duncan Jones
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package qif
+
+import (
+	"bytes"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// onlyReader hides any methods other than Read, preventing seeking.
+type onlyReader struct {
+	io.Reader
+}
+
+func detectInput(dates ...string) string {
+	lines := []string{bankHeader}
+	for _, d := range dates {
+		lines = append(lines, "D"+d, "T1.00", recordEnd)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestDetectDayFirst(t *testing.T) {
+	input := detectInput("1/2/18", "25/12/18")
+
+	for _, in := range []io.Reader{
+		strings.NewReader(input),
+		onlyReader{strings.NewReader(input)},
+	} {
+		r := NewReaderWithConfig(in, Config{DetectDateOrder: true})
+
+		txs, err := r.ReadAll()
+		require.NoError(t, err)
+		require.Len(t, txs, 2)
+		assert.True(t, r.DayFirst())
+
+		assert.Equal(t, time.February, txs[0].Date().Month())
+		assert.Equal(t, 1, txs[0].Date().Day())
+	}
+}
+
+func TestDetectMonthFirst(t *testing.T) {
+	r := NewReaderWithConfig(strings.NewReader(detectInput("1/2/18", "12/25/18")),
+		Config{DayFirst: true, DetectDateOrder: true})
+
+	txs, err := r.ReadAll()
+	require.NoError(t, err)
+	assert.False(t, r.DayFirst())
+	assert.Equal(t, time.January, txs[0].Date().Month())
+}
+
+func TestDetectAmbiguous(t *testing.T) {
+	r := NewReaderWithConfig(strings.NewReader(detectInput("1/1/18", "1/2/18")),
+		Config{DetectDateOrder: true})
+
+	_, err := r.ReadAll()
+	assert.True(t, errors.Is(err, ErrAmbiguousDateOrder))
+
+	var pe *ParseError
+	require.True(t, errors.As(err, &pe))
+	assert.Equal(t, 5, pe.Line)
+	assert.Equal(t, byte('D'), pe.Field)
+}
+
+func TestDetectErrorRepeated(t *testing.T) {
+	input := detectInput("1/2/18")
+	r := NewReaderWithConfig(strings.NewReader(input),
+		Config{DetectDateOrder: true})
+
+	// Later reads must not fall back to the default date order
+	for i := 0; i < 2; i++ {
+		tx, err := r.Read()
+		assert.Nil(t, tx)
+		assert.True(t, errors.Is(err, ErrAmbiguousDateOrder))
+
+		var pe *ParseError
+		require.True(t, errors.As(err, &pe))
+		assert.Equal(t, 2, pe.Line)
+		assert.Equal(t, int64(len(bankHeader)+1), pe.Offset)
+	}
+
+	_, err := r.ReadAll()
+	assert.True(t, errors.Is(err, ErrAmbiguousDateOrder))
+}
+
+func TestDetectInconsistent(t *testing.T) {
+	r := NewReaderWithConfig(
+		strings.NewReader(detectInput("25/12/18", "1/2/18", "12/25/18")),
+		Config{DetectDateOrder: true})
+
+	_, err := r.ReadAll()
+	assert.True(t, errors.Is(err, ErrInconsistentDateOrder))
+
+	var pe *ParseError
+	require.True(t, errors.As(err, &pe))
+	assert.Equal(t, 8, pe.Line)
+}
+
+func TestDetectNoAmbiguity(t *testing.T) {
+	r := NewReaderWithConfig(strings.NewReader(detectInput("1/1/18", "2/2/18")),
+		Config{DayFirst: true, DetectDateOrder: true})
+
+	_, err := r.ReadAll()
+	require.NoError(t, err)
+	assert.True(t, r.DayFirst())
+}
+
+func TestDetectOtherSections(t *testing.T) {
+	inputData := strings.Join([]string{
+		categoryHeader,
+		"N1/2",
+		"D3/4",
+		recordEnd,
+		pricesHeader,
+		`"ACME",1.00,"1/ 2/18"`,
+		recordEnd,
+		accountHeader,
+		"NChecking",
+		"/31/12/17",
+		recordEnd,
+	}, "\n")
+
+	r := NewReaderWithConfig(strings.NewReader(inputData),
+		Config{DetectDateOrder: true})
+
+	recs, err := r.ReadAllRecords()
+	require.NoError(t, err)
+	require.Len(t, recs, 3)
+	assert.True(t, r.DayFirst())
+	assert.Equal(t, time.February, recs[1].(Price).Date().Month())
+}
+
+func TestDetectSpecExample(t *testing.T) {
+	// All the dates in this file are ambiguous (e.g. 6/ 1/94)
+	input, err := os.Open("testdata/example1.qif")
+	require.NoError(t, err)
+	defer input.Close()
+
+	r := NewReaderWithConfig(input, Config{DetectDateOrder: true})
+	_, err = r.ReadAll()
+	assert.True(t, errors.Is(err, ErrAmbiguousDateOrder))
+}
+
+func TestDetectReadError(t *testing.T) {
+	r := NewReaderWithConfig(io.MultiReader(bytes.NewReader([]byte(bankHeader)),
+		errReader{}), Config{DetectDateOrder: true})
+
+	_, err := r.Read()
+
+	var pe *ParseError
+	assert.True(t, errors.As(err, &pe))
+}
+
+type errReader struct{}
+
+func (errReader) Read([]byte) (int, error) {
+	return 0, errors.New("read failed")
+}
+
+func TestDetectNormalisedLines(t *testing.T) {
+	input := "!type:BANK \r D1/2/18\r^\r\r D25/12/18 \r^\r"
+
+	r := NewReaderWithConfig(strings.NewReader(input),
+		Config{DetectDateOrder: true})
+
+	txs, err := r.ReadAll()
+	require.NoError(t, err)
+	require.Len(t, txs, 2)
+	assert.True(t, r.DayFirst())
+}
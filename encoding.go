@@ -0,0 +1,306 @@
+//   Copyright 2018 Duncan Jones
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package qif
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Encoding identifies the character encoding of QIF data.
+type Encoding int
+
+const (
+	// AutoEncoding detects the encoding of the input. A byte order mark
+	// identifies UTF-8 or UTF-16, and UTF-16 without a byte order mark is
+	// recognised by the zero bytes in the first character. Otherwise, the
+	// input is read as UTF-8, except for lines that are not valid UTF-8,
+	// which are read as Windows-1252. When writing, UTF-8 is used.
+	AutoEncoding Encoding = iota
+
+	// UTF8 is the UTF-8 encoding. Invalid bytes are left unchanged.
+	UTF8
+
+	// UTF16LE is the little-endian UTF-16 encoding, used by Windows.
+	UTF16LE
+
+	// UTF16BE is the big-endian UTF-16 encoding.
+	UTF16BE
+
+	// Windows1252 is the Windows code page used by older versions of Quicken
+	// and Microsoft Money in western Europe and the Americas.
+	Windows1252
+
+	// Latin1 is the ISO 8859-1 encoding.
+	Latin1
+)
+
+// String returns the name of the encoding.
+func (e Encoding) String() string {
+	switch e {
+	case AutoEncoding:
+		return "Auto"
+	case UTF8:
+		return "UTF-8"
+	case UTF16LE:
+		return "UTF-16LE"
+	case UTF16BE:
+		return "UTF-16BE"
+	case Windows1252:
+		return "Windows-1252"
+	case Latin1:
+		return "ISO-8859-1"
+	default:
+		return "Unknown"
+	}
+}
+
+var (
+	utf8BOM    = []byte{0xef, 0xbb, 0xbf}
+	utf16LEBOM = []byte{0xff, 0xfe}
+	utf16BEBOM = []byte{0xfe, 0xff}
+)
+
+// windows1252 maps the bytes 0x80 to 0x9f of Windows-1252 to runes. The other
+// bytes have the same values as in Latin-1. Unused bytes map to the
+// corresponding control characters.
+var windows1252 = [32]rune{
+	'€', '\u0081', '‚', 'ƒ', '„', '…', '†',
+	'‡', 'ˆ', '‰', 'Š', '‹', 'Œ', '\u008d',
+	'Ž', '\u008f', '\u0090', '‘', '’', '“', '”',
+	'•', '–', '—', '˜', '™', 'š', '›',
+	'œ', '\u009d', 'ž', 'Ÿ',
+}
+
+// decodeInput prepares the input for scanning. The encoding is detected if
+// enc is AutoEncoding, and any byte order mark is removed. UTF-16 input is
+// converted to UTF-8; single-byte encodings are converted a line at a time by
+// decodeLine. It returns the encoding of the input, to pass to decodeLine and
+// rawLength, and the length of the byte order mark.
+func decodeInput(in io.Reader, enc Encoding) (out io.Reader, inputEnc Encoding,
+	bomLen int) {
+	br := bufio.NewReader(in)
+	start, _ := br.Peek(len(utf8BOM))
+
+	switch {
+	case bytes.HasPrefix(start, utf8BOM) &&
+		(enc == AutoEncoding || enc == UTF8):
+		bomLen = len(utf8BOM)
+
+	case bytes.HasPrefix(start, utf16LEBOM) &&
+		(enc == AutoEncoding || enc == UTF16LE):
+		enc, bomLen = UTF16LE, len(utf16LEBOM)
+
+	case bytes.HasPrefix(start, utf16BEBOM) &&
+		(enc == AutoEncoding || enc == UTF16BE):
+		enc, bomLen = UTF16BE, len(utf16BEBOM)
+
+	case enc == AutoEncoding && len(start) >= 2:
+		// QIF data starts with an ASCII character, so the zero byte of the
+		// first UTF-16 code unit gives away the byte order
+		if start[0] != 0 && start[1] == 0 {
+			enc = UTF16LE
+		} else if start[0] == 0 && start[1] != 0 {
+			enc = UTF16BE
+		}
+	}
+
+	br.Discard(bomLen)
+
+	switch enc {
+	case UTF16LE, UTF16BE:
+		return &utf16Reader{in: br, bigEndian: enc == UTF16BE}, enc, bomLen
+	default:
+		return br, enc, bomLen
+	}
+}
+
+// decodeLine converts a line of input in the given encoding to UTF-8. UTF-16
+// input has already been converted by decodeInput.
+func decodeLine(line []byte, enc Encoding) string {
+	switch enc {
+	case AutoEncoding:
+		if utf8.Valid(line) {
+			return string(line)
+		}
+		return decodeSingleByte(line, true)
+	case Windows1252:
+		return decodeSingleByte(line, true)
+	case Latin1:
+		return decodeSingleByte(line, false)
+	default:
+		return string(line)
+	}
+}
+
+// decodeSingleByte converts Latin-1, or Windows-1252 if windows is true, to
+// UTF-8.
+func decodeSingleByte(line []byte, windows bool) string {
+	runes := make([]rune, len(line))
+	for i, b := range line {
+		runes[i] = rune(b)
+		if windows && b >= 0x80 && b < 0xa0 {
+			runes[i] = windows1252[b-0x80]
+		}
+	}
+
+	return string(runes)
+}
+
+// rawLength returns the number of bytes of input in the given encoding that
+// were converted by decodeInput to produce data, so that positions can be
+// reported in terms of the original input. A trailing odd byte of UTF-16 input
+// is counted as a whole code unit.
+func rawLength(data []byte, enc Encoding) int64 {
+	if enc != UTF16LE && enc != UTF16BE {
+		return int64(len(data))
+	}
+
+	var units int64
+	for _, r := range string(data) {
+		units += int64(utf16.RuneLen(r))
+	}
+
+	return 2 * units
+}
+
+// encodeLine converts a UTF-8 line to the given encoding. Characters that
+// cannot be represented are replaced with '?'.
+func encodeLine(line string, enc Encoding) []byte {
+	switch enc {
+	case UTF16LE, UTF16BE:
+		units := utf16.Encode([]rune(line))
+		out := make([]byte, 0, 2*len(units))
+		for _, u := range units {
+			if enc == UTF16BE {
+				out = append(out, byte(u>>8), byte(u))
+			} else {
+				out = append(out, byte(u), byte(u>>8))
+			}
+		}
+		return out
+
+	case Windows1252, Latin1:
+		out := make([]byte, 0, len(line))
+		for _, r := range line {
+			out = append(out, encodeSingleByte(r, enc == Windows1252))
+		}
+		return out
+
+	default:
+		return []byte(line)
+	}
+}
+
+// encodeSingleByte converts a rune to Latin-1, or Windows-1252 if windows is
+// true. It returns '?' if the rune cannot be represented.
+func encodeSingleByte(r rune, windows bool) byte {
+	if windows {
+		for i, w := range windows1252 {
+			if w == r {
+				return byte(0x80 + i)
+			}
+		}
+
+		if r >= 0x80 && r < 0xa0 {
+			return '?'
+		}
+	}
+
+	if r < 0x100 {
+		return byte(r)
+	}
+
+	return '?'
+}
+
+// utf16Reader converts UTF-16 input to UTF-8. Unpaired surrogates and a
+// trailing odd byte are replaced with U+FFFD.
+type utf16Reader struct {
+	in        *bufio.Reader
+	bigEndian bool
+
+	// buf holds converted data that has not yet been read.
+	buf []byte
+}
+
+func (u *utf16Reader) Read(p []byte) (int, error) {
+	for len(u.buf) < len(p) {
+		r, err := u.readRune()
+		if err != nil {
+			if len(u.buf) > 0 {
+				break
+			}
+			return 0, err
+		}
+
+		u.buf = append(u.buf, string(r)...)
+	}
+
+	n := copy(p, u.buf)
+	u.buf = u.buf[n:]
+	return n, nil
+}
+
+// readRune reads a character, which may be made up of two code units.
+func (u *utf16Reader) readRune() (rune, error) {
+	first, err := u.readUnit()
+	if err != nil {
+		return 0, err
+	}
+
+	if !utf16.IsSurrogate(first) {
+		return first, nil
+	}
+
+	// Look at the next unit without consuming it, in case it is not the
+	// second half of a pair
+	next, _ := u.in.Peek(2)
+	if len(next) == 2 {
+		second := u.unit(next)
+		if r := utf16.DecodeRune(first, second); r != utf8.RuneError {
+			u.in.Discard(2)
+			return r, nil
+		}
+	}
+
+	return utf8.RuneError, nil
+}
+
+// readUnit reads a UTF-16 code unit.
+func (u *utf16Reader) readUnit() (rune, error) {
+	var b [2]byte
+	n, err := io.ReadFull(u.in, b[:])
+	switch {
+	case n == 2:
+		return u.unit(b[:]), nil
+	case n == 1:
+		return utf8.RuneError, nil
+	default:
+		return 0, err
+	}
+}
+
+// unit decodes the code unit held in two bytes.
+func (u *utf16Reader) unit(b []byte) rune {
+	if u.bigEndian {
+		return rune(b[0])<<8 | rune(b[1])
+	}
+
+	return rune(b[1])<<8 | rune(b[0])
+}
@@ -0,0 +1,228 @@
+//   Copyright 2018 Duncan Jones
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package qif
+
+import (
+	"bufio"
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+// encodedInput returns a banking transaction with an accented payee in the
+// given encoding.
+func encodedInput(enc Encoding) []byte {
+	return encodeLine(strings.Join([]string{
+		bankHeader,
+		"D03/01/2018",
+		"T-12.99",
+		"PCafé “Zoë” – €5",
+		recordEnd,
+	}, "\n"), enc)
+}
+
+func TestDecodeLine(t *testing.T) {
+	input := []byte("Caf\xe9 \x80\x93\x81")
+
+	assert.Equal(t, "Café €“\u0081", decodeLine(input, Windows1252))
+	assert.Equal(t, "Café \u0080\u0093\u0081", decodeLine(input, Latin1))
+	assert.Equal(t, "Café €“\u0081", decodeLine(input, AutoEncoding))
+	assert.Equal(t, string(input), decodeLine(input, UTF8))
+
+	// Valid UTF-8 is not converted when detecting the encoding
+	assert.Equal(t, "Café", decodeLine([]byte("Café"), AutoEncoding))
+}
+
+func TestEncodeLine(t *testing.T) {
+	assert.Equal(t, []byte("Caf\xe9 \x80\x96?"),
+		encodeLine("Café €–✓", Windows1252))
+	assert.Equal(t, []byte("Caf\xe9 ??"), encodeLine("Café €–", Latin1))
+	assert.Equal(t, []byte("A\x00\x3d\xd8\x00\xde"),
+		encodeLine("A😀", UTF16LE))
+	assert.Equal(t, []byte("\x00A\xd8\x3d\xde\x00"),
+		encodeLine("A😀", UTF16BE))
+	assert.Equal(t, []byte("Café"), encodeLine("Café", AutoEncoding))
+}
+
+func TestUTF16Reader(t *testing.T) {
+	vectors := map[string]string{
+		"A\x00\x3d\xd8\x00\xde": "A😀",
+		"A\x00\x3d\xd8B\x00":    "A�B",
+		"\x00\xdeB\x00":         "�B",
+		"A\x00B":                "A�",
+	}
+
+	for k, v := range vectors {
+		in := &utf16Reader{in: bufio.NewReader(strings.NewReader(k))}
+		out, err := ioutil.ReadAll(in)
+		require.NoError(t, err)
+		assert.Equalf(t, v, string(out), "error processing % x", k)
+	}
+}
+
+func TestReadEncodings(t *testing.T) {
+	vectors := map[Encoding][]byte{
+		UTF8:        encodedInput(UTF8),
+		UTF16LE:     encodedInput(UTF16LE),
+		UTF16BE:     encodedInput(UTF16BE),
+		Windows1252: []byte("!Type:Bank\nP\x93Zo\xeb\x94\n^"),
+	}
+
+	for enc, input := range vectors {
+		for _, config := range []Config{{Encoding: enc}, {}} {
+			tx, err := NewReaderWithConfig(bytes.NewReader(input),
+				config).Read()
+			require.NoErrorf(t, err, "error processing %v", enc)
+
+			payee := tx.(BankingTransaction).Payee()
+			if enc == Windows1252 {
+				assert.Equal(t, "“Zoë”", payee)
+			} else {
+				assert.Equalf(t, "Café “Zoë” – €5", payee,
+					"error processing %v", enc)
+			}
+		}
+	}
+}
+
+func TestReadLatin1(t *testing.T) {
+	input := []byte("!Type:Bank\nPZo\xeb\x80\n^")
+
+	tx, err := NewReaderWithConfig(bytes.NewReader(input),
+		Config{Encoding: Latin1}).Read()
+	require.NoError(t, err)
+	assert.Equal(t, "Zoë\u0080", tx.(BankingTransaction).Payee())
+}
+
+func TestReadByteOrderMarks(t *testing.T) {
+	vectors := map[string][]byte{
+		"UTF-8":    append(utf8BOM, encodedInput(UTF8)...),
+		"UTF-16LE": append(utf16LEBOM, encodedInput(UTF16LE)...),
+		"UTF-16BE": append(utf16BEBOM, encodedInput(UTF16BE)...),
+	}
+
+	for k, input := range vectors {
+		r := NewReader(bytes.NewReader(input))
+		tx, err := r.Read()
+		require.NoErrorf(t, err, "error processing %s", k)
+		assert.Equalf(t, "Café “Zoë” – €5", tx.(BankingTransaction).Payee(),
+			"error processing %s", k)
+	}
+}
+
+func TestByteOrderMarkOffset(t *testing.T) {
+	input := append(utf8BOM, []byte(bankHeader+"\nDbad\n^")...)
+
+	_, err := NewReader(bytes.NewReader(input)).Read()
+	require.Error(t, err)
+
+	pe, ok := err.(*ParseError)
+	require.True(t, ok)
+	assert.Equal(t, 2, pe.Line)
+	assert.Equal(t, int64(len(utf8BOM)+len(bankHeader)+1), pe.Offset)
+}
+
+func TestUTF16Offsets(t *testing.T) {
+	input := append(utf16LEBOM, encodeLine(strings.Join([]string{
+		bankHeader,
+		"PZoë😀",
+		recordEnd,
+		"Dbad",
+		recordEnd,
+	}, "\r\n"), UTF16LE)...)
+
+	var progress []int64
+	config := Config{Progress: func(bytes int64, records int) {
+		progress = append(progress, bytes)
+	}}
+
+	r := NewReaderWithConfig(bytes.NewReader(input), config)
+	_, err := r.Read()
+	require.NoError(t, err)
+
+	_, err = r.Read()
+	require.Error(t, err)
+
+	// Offsets are positions in the original input, including the BOM
+	pe, ok := err.(*ParseError)
+	require.True(t, ok)
+	// 'ë' is one UTF-16 code unit, and the emoji is two
+	start := len(utf16LEBOM) + 2*len(bankHeader+"\r\nPZo\r\n^\r\n") + 2 + 4
+	assert.Equal(t, int64(start), pe.Offset)
+	assert.Equal(t, int64(start), progress[0])
+
+	r = NewReaderWithConfig(bytes.NewReader(input),
+		Config{SkipBadRecords: true, Progress: config.Progress})
+	_, err = r.ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(input)), progress[len(progress)-1])
+}
+
+func TestDetectDateOrderUTF16(t *testing.T) {
+	input := append(utf16LEBOM, encodeLine(strings.Join([]string{
+		bankHeader,
+		"D31/01/2018",
+		"T-12.99",
+		recordEnd,
+	}, "\n"), UTF16LE)...)
+
+	r := NewReaderWithConfig(bytes.NewReader(input),
+		Config{DetectDateOrder: true})
+	tx, err := r.Read()
+	require.NoError(t, err)
+	assert.True(t, r.DayFirst())
+	assert.Equal(t, 31, tx.Date().Day())
+}
+
+func TestWriteEncodings(t *testing.T) {
+	original, err := NewReader(bytes.NewReader(encodedInput(UTF8))).ReadAll()
+	require.NoError(t, err)
+
+	for _, enc := range []Encoding{AutoEncoding, UTF8, UTF16LE, UTF16BE,
+		Windows1252, Latin1} {
+		var buf bytes.Buffer
+		w := NewWriterWithConfig(&buf, Config{Encoding: enc})
+		require.NoError(t, w.WriteAll(original))
+
+		switch enc {
+		case UTF16LE:
+			assert.True(t, bytes.HasPrefix(buf.Bytes(), utf16LEBOM))
+		case UTF16BE:
+			assert.True(t, bytes.HasPrefix(buf.Bytes(), utf16BEBOM))
+		}
+
+		result, err := NewReaderWithConfig(&buf,
+			Config{Encoding: enc}).ReadAll()
+		require.NoErrorf(t, err, "error processing %v", enc)
+		require.Len(t, result, 1)
+
+		payee := result[0].(BankingTransaction).Payee()
+		if enc == Latin1 {
+			assert.Equal(t, "Café ?Zoë? ? ?5", payee)
+		} else {
+			assert.Equalf(t, "Café “Zoë” – €5", payee,
+				"error processing %v", enc)
+		}
+	}
+}
+
+func TestEncodingString(t *testing.T) {
+	assert.Equal(t, "Windows-1252", Windows1252.String())
+	assert.Equal(t, "UTF-16LE", UTF16LE.String())
+	assert.Equal(t, "Unknown", Encoding(99).String())
+}
@@ -14,19 +14,118 @@
 
 package qif
 
-import "fmt"
+import (
+	"fmt"
 
-// A RecordEndError is returned if the input data finishes without a terminating
-// '^' character. All records should be terminated in a QIF file, but an
-// application may wish to be forgiving if the last record is not terminated.
-// The non-terminated transaction can be found as a field within the error.
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrAmbiguousDateOrder is returned (wrapped in a ParseError) if
+	// Config.DetectDateOrder is set but every date in the input could be read
+	// as either mm/dd or dd/mm.
+	ErrAmbiguousDateOrder = errors.New("date order is ambiguous")
+
+	// ErrInconsistentDateOrder is returned (wrapped in a ParseError) if
+	// Config.DetectDateOrder is set but the input contains dates that are only
+	// valid as mm/dd and others that are only valid as dd/mm.
+	ErrInconsistentDateOrder = errors.New("date order is inconsistent")
+
+	// ErrLineTooLong is returned (wrapped in a ParseError) if a line is
+	// longer than Config.MaxLineLength.
+	ErrLineTooLong = errors.New(
+		"line exceeds maximum length (see Config.MaxLineLength)")
+)
+
+// A ParseError is returned for any failure while reading QIF data. It records
+// the position of the problem in the input. The underlying error and any
+// errors it wraps can be found with errors.Is and errors.As, or with
+// errors.Cause from github.com/pkg/errors.
+type ParseError struct {
+
+	// Line is the line number of the problem, counting from one. If the input
+	// ended unexpectedly, this is the number of lines read.
+	Line int
+
+	// Offset is the byte offset of the start of the line within the input.
+	Offset int64
+
+	// Record is the index of the record containing the problem, counting from
+	// zero. All record types are counted, not just transactions.
+	Record int
+
+	// Field is the field code of the line (e.g. 'D' for a date), or zero if
+	// the line is not a field.
+	Field byte
+
+	// Text is the raw text of the line, or empty if the problem did not relate
+	// to a specific line.
+	Text string
+
+	// Err is the underlying error.
+	Err error
+}
+
+func (e *ParseError) Error() string {
+	if e.Field != 0 {
+		return fmt.Sprintf("line %d, field '%c': %v", e.Line, e.Field, e.Err)
+	}
+
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// Cause returns the underlying error, for use with errors.Cause from
+// github.com/pkg/errors.
+func (e *ParseError) Cause() error {
+	return e.Err
+}
+
+// An UnsupportedFieldError is returned if a line has a field code that is not
+// valid for the type of record being read. See Config.KeepUnknownFields.
+type UnsupportedFieldError struct {
+
+	// Line is the text of the line.
+	Line string
+}
+
+func (e UnsupportedFieldError) Error() string {
+	return fmt.Sprintf("cannot process line '%s'", e.Line)
+}
+
+// A RecordEndError is returned (wrapped in a ParseError) if the input data
+// finishes without a terminating '^' character. All records should be
+// terminated in a QIF file, but an application may wish to be forgiving if the
+// last record is not terminated. The non-terminated transaction can be found
+// as a field within the error.
 type RecordEndError struct {
 
 	// Incomplete is the transaction that was being parsed when the input
-	// ended.
+	// ended. It is nil if the unterminated record was not a transaction (for
+	// instance, an account).
 	Incomplete Transaction
 }
 
 func (RecordEndError) Error() string {
 	return fmt.Sprintf("unexpected end of input")
 }
+
+// A SplitSumError is returned by CheckSplits if the split amounts of a
+// transaction do not add up to its total amount.
+type SplitSumError struct {
+
+	// Total is the amount of the transaction.
+	Total Decimal
+
+	// Sum is the sum of the split amounts.
+	Sum Decimal
+}
+
+func (e *SplitSumError) Error() string {
+	return fmt.Sprintf("splits add up to %v, not the transaction amount %v",
+		e.Sum, e.Total)
+}
@@ -0,0 +1,118 @@
+//   Copyright 2018 Duncan Jones
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package qif
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// A FieldHandler processes a custom field of a transaction. It receives the
+// value of the field (the line without its code) and the transaction being
+// read, which contains the fields that preceded it. The result is stored in
+// the transaction's Extensions map under the field code, replacing any earlier
+// result for the same code. Returning an error fails the record.
+type FieldHandler func(value string, tx Transaction) (interface{}, error)
+
+// extensible is implemented by all transaction types.
+type extensible interface {
+	setExtension(code byte, value interface{})
+}
+
+// HandleField registers a handler for a field code in a section of the input.
+// The section is named as in its header, without the "!Type:" prefix: "Bank",
+// "Cash", "CCard", "Invst", "Oth A", "Oth L" or "Memorized". Like headers,
+// section names are not case-sensitive. Handlers are only called for codes the
+// reader does not understand, so built-in fields cannot be replaced. A nil
+// handler removes the registration.
+//
+// If KeepUnknownFields is also set, handled fields are kept in
+// RawRecord.Extra as well, so the writer outputs them.
+func (c *Config) HandleField(section string, code byte, handler FieldHandler) {
+	section = sectionName(section)
+
+	if handler == nil {
+		delete(c.FieldHandlers[section], code)
+		return
+	}
+
+	if c.FieldHandlers == nil {
+		c.FieldHandlers = make(map[string]map[byte]FieldHandler)
+	}
+
+	if c.FieldHandlers[section] == nil {
+		c.FieldHandlers[section] = make(map[byte]FieldHandler)
+	}
+
+	c.FieldHandlers[section][code] = handler
+}
+
+// sectionName gives a section name the capitalisation used by the header
+// constants (e.g. "Bank" for "bank").
+func sectionName(section string) string {
+	header := normaliseLine(typePrefix + strings.TrimSpace(section))
+	return strings.TrimPrefix(header, typePrefix)
+}
+
+// fieldHandler returns the handler registered for a field code in a section,
+// or nil if there is none. Sections added to Config.FieldHandlers directly
+// may not have been normalised, so they are compared ignoring case.
+func (r *reader) fieldHandler(section string, code byte) FieldHandler {
+	if handler := r.config.FieldHandlers[section][code]; handler != nil {
+		return handler
+	}
+
+	for name, handlers := range r.config.FieldHandlers {
+		if strings.EqualFold(name, section) && handlers[code] != nil {
+			return handlers[code]
+		}
+	}
+
+	return nil
+}
+
+// handleUnknownField deals with a field the record does not understand,
+// passing it to a registered handler and keeping it if KeepUnknownFields is
+// set. It returns UnsupportedFieldError if neither applies.
+func (r *reader) handleUnknownField(rec Record, line string) error {
+	code, value := line[0], line[1:]
+	handled := false
+
+	handler := r.fieldHandler(sectionName(strings.TrimPrefix(r.header,
+		typePrefix)), code)
+
+	if tx, ok := rec.(Transaction); ok && handler != nil {
+		result, err := handler(value, tx)
+		if err != nil {
+			return errors.Wrapf(err, "failed to handle field '%c'", code)
+		}
+
+		rec.(extensible).setExtension(code, result)
+		handled = true
+	}
+
+	if r.config.KeepUnknownFields {
+		rd := rec.(rawRecord).raw()
+		rd.extra = append(rd.extra, Field{Code: code, Value: value})
+		handled = true
+	}
+
+	if !handled {
+		return UnsupportedFieldError{Line: line}
+	}
+
+	return nil
+}
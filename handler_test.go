@@ -0,0 +1,180 @@
+//   Copyright 2018 Duncan Jones
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package qif
+
+import (
+	"bytes"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// customFieldsInput contains banking and investment transactions with a
+// custom 'Z' field.
+var customFieldsInput = strings.Join([]string{
+	bankHeader,
+	"D03/01/2018",
+	"T-12.99",
+	"Z42",
+	recordEnd,
+	investmentHeader,
+	"D03/01/2018",
+	"NBuy",
+	"Z7",
+	recordEnd,
+}, "\n")
+
+func TestHandleField(t *testing.T) {
+	var config Config
+	config.HandleField("Bank", 'Z', func(value string,
+		tx Transaction) (interface{}, error) {
+		assert.Equal(t, dec("-12.99"), tx.Amount())
+		return strconv.Atoi(value)
+	})
+
+	r := NewReaderWithConfig(strings.NewReader(customFieldsInput), config)
+	tx, err := r.Read()
+	require.NoError(t, err)
+	assert.Equal(t, map[byte]interface{}{'Z': 42}, tx.Extensions())
+	assert.Empty(t, tx.Extra())
+
+	// The handler does not apply to other sections
+	_, err = r.Read()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot process line 'Z7'")
+}
+
+func TestHandleFieldRepeated(t *testing.T) {
+	input := strings.Join([]string{
+		memorizedHeader,
+		"KP",
+		"T-12.99",
+		"Zfirst",
+		"Zsecond",
+		recordEnd,
+	}, "\n")
+
+	var config Config
+	config.HandleField("Memorized", 'Z', func(value string,
+		tx Transaction) (interface{}, error) {
+		tags, _ := tx.Extensions()['Z'].([]string)
+		return append(tags, value), nil
+	})
+
+	rec, err := NewReaderWithConfig(strings.NewReader(input),
+		config).ReadRecord()
+	require.NoError(t, err)
+	tx := rec.(MemorizedTransaction)
+	assert.Equal(t, []string{"first", "second"}, tx.Extensions()['Z'])
+}
+
+func TestHandleFieldError(t *testing.T) {
+	errBadValue := errors.New("bad value")
+
+	var config Config
+	config.HandleField("Bank", 'Z', func(value string,
+		tx Transaction) (interface{}, error) {
+		return nil, errBadValue
+	})
+
+	_, err := NewReaderWithConfig(strings.NewReader(customFieldsInput),
+		config).Read()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to handle field 'Z': bad value")
+
+	// The handler's own error can be found through the ParseError
+	assert.True(t, errors.Is(err, errBadValue))
+	assert.Equal(t, errBadValue, errors.Cause(err))
+}
+
+func TestHandleFieldBuiltInCode(t *testing.T) {
+	config := Config{KeepUnknownFields: true}
+	config.HandleField("Bank", 'T', func(value string,
+		tx Transaction) (interface{}, error) {
+		return nil, errors.New("not called")
+	})
+
+	tx, err := NewReaderWithConfig(strings.NewReader(customFieldsInput),
+		config).Read()
+	require.NoError(t, err)
+	assert.Equal(t, dec("-12.99"), tx.Amount())
+	assert.Nil(t, tx.Extensions())
+}
+
+func TestHandleFieldKeepUnknownFields(t *testing.T) {
+	config := Config{KeepUnknownFields: true}
+	config.HandleField("Invst", 'Z', func(value string,
+		tx Transaction) (interface{}, error) {
+		return value, nil
+	})
+
+	txs, err := NewReaderWithConfig(strings.NewReader(customFieldsInput),
+		config).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, txs, 2)
+	assert.Equal(t, map[byte]interface{}{'Z': "7"}, txs[1].Extensions())
+	assert.Equal(t, []Field{{Code: 'Z', Value: "7"}}, txs[1].Extra())
+
+	var buf bytes.Buffer
+	require.NoError(t, NewWriter(&buf).WriteAll(txs))
+	assert.Contains(t, buf.String(), "\nZ7\n^\n")
+}
+
+func TestRemoveFieldHandler(t *testing.T) {
+	var config Config
+	config.HandleField("Bank", 'Z', nil)
+	assert.Empty(t, config.FieldHandlers)
+
+	config.HandleField("Bank", 'Z', func(value string,
+		tx Transaction) (interface{}, error) {
+		return value, nil
+	})
+	config.HandleField("Bank", 'Z', nil)
+	assert.Empty(t, config.FieldHandlers["Bank"])
+}
+
+func TestHandleFieldSectionCase(t *testing.T) {
+	input := strings.Join([]string{
+		"!type:bank",
+		"T-12.99",
+		"Z42",
+		recordEnd,
+	}, "\n")
+
+	handler := func(value string, tx Transaction) (interface{}, error) {
+		return value, nil
+	}
+
+	var registered Config
+	registered.HandleField("bank", 'Z', handler)
+	assert.Contains(t, registered.FieldHandlers, "Bank")
+
+	configs := []Config{
+		registered,
+		{FieldHandlers: map[string]map[byte]FieldHandler{
+			"BANK": {'Z': handler},
+		}},
+	}
+
+	for _, config := range configs {
+		tx, err := NewReaderWithConfig(strings.NewReader(input),
+			config).Read()
+		require.NoError(t, err)
+		assert.Equal(t, map[byte]interface{}{'Z': "42"}, tx.Extensions())
+	}
+}
@@ -0,0 +1,171 @@
+//   Copyright 2018 Duncan Jones
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package qif
+
+import "github.com/pkg/errors"
+
+// An InvestmentTransaction contains the information associated with
+// transactions in investment accounts (i.e. the Invst account type).
+type InvestmentTransaction interface {
+	Transaction
+
+	// Action describes the type of investment transaction. Common values
+	// include "Buy", "Sell", "Div", "ReinvDiv", "ShrsIn" and "XIn". Actions
+	// ending in 'X' (e.g. "BuyX") indicate the cash was transferred to or from
+	// another account.
+	Action() string
+
+	// Security is the name of the security bought, sold or otherwise affected
+	// by the transaction.
+	Security() string
+
+	// Price is the price per share of the security, with the decimal places
+	// given in the input data.
+	Price() Decimal
+
+	// Quantity is the number of shares involved in the transaction, with the
+	// decimal places given in the input data.
+	Quantity() Decimal
+
+	// Commission stores the cost of the transaction, with the number of
+	// decimal places used by Currency.
+	Commission() Decimal
+
+	// Payee contains the text of the first line of the transaction, which is
+	// used for transfers and reminders.
+	Payee() string
+
+	// TransferAccount is the account involved in a transfer, if any.
+	TransferAccount() string
+
+	// TransferAmount stores the amount transferred to or from TransferAccount,
+	// with the number of decimal places used by Currency.
+	TransferAmount() Decimal
+}
+
+type investmentTransaction struct {
+	transaction
+	action          string
+	security        string
+	price           Decimal
+	quantity        Decimal
+	commission      Decimal
+	payee           string
+	transferAccount string
+	transferAmount  Decimal
+}
+
+func (t *investmentTransaction) Action() string {
+	return t.action
+}
+
+func (t *investmentTransaction) Security() string {
+	return t.security
+}
+
+func (t *investmentTransaction) Price() Decimal {
+	return t.price
+}
+
+func (t *investmentTransaction) Quantity() Decimal {
+	return t.quantity
+}
+
+func (t *investmentTransaction) Commission() Decimal {
+	return t.commission
+}
+
+func (t *investmentTransaction) Payee() string {
+	return t.payee
+}
+
+func (t *investmentTransaction) TransferAccount() string {
+	return t.transferAccount
+}
+
+func (t *investmentTransaction) TransferAmount() Decimal {
+	return t.transferAmount
+}
+
+func (t *investmentTransaction) parseInvestmentTransactionField(line string,
+	config Config) error {
+	if line == "" {
+		return errors.New("line is empty")
+	}
+
+	err := t.parseTransactionField(line, config)
+	if err == nil {
+		// Must have been a field from our embedded struct
+		return nil
+	}
+
+	if _, ok := err.(UnsupportedFieldError); !ok {
+		// An actual error happened
+		return err
+	}
+
+	switch line[0] {
+	case 'N':
+		t.action = line[1:]
+		return nil
+
+	case 'Y':
+		t.security = line[1:]
+		return nil
+
+	case 'I':
+		price, err := parseNumber(line[1:], config)
+		if err != nil {
+			return errors.Wrap(err, "failed to parse price")
+		}
+		t.price = price
+		return nil
+
+	case 'Q':
+		qty, err := parseNumber(line[1:], config)
+		if err != nil {
+			return errors.Wrap(err, "failed to parse quantity")
+		}
+		t.quantity = qty
+		return nil
+
+	case 'O':
+		amt, err := parseAmount(line[1:], t.currency, config)
+		if err != nil {
+			return errors.Wrap(err, "failed to parse commission")
+		}
+		t.commission = amt
+		return nil
+
+	case 'P':
+		t.payee = line[1:]
+		return nil
+
+	case 'L':
+		t.transferAccount = line[1:]
+		return nil
+
+	case '$':
+		amt, err := parseAmount(line[1:], t.currency, config)
+		if err != nil {
+			return errors.Wrap(err, "failed to parse transfer amount")
+		}
+		t.transferAmount = amt
+		return nil
+
+	default:
+		return UnsupportedFieldError{Line: line}
+	}
+}
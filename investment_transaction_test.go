@@ -0,0 +1,80 @@
+//   Copyright 2018 Duncan Jones
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package qif
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestInvestmentFields(t *testing.T) {
+	tx := &investmentTransaction{}
+
+	lines := []string{
+		"NBuyX",
+		"YAcme Corp",
+		"I101.125",
+		"Q12.3456",
+		"O9.95",
+		"PBought shares",
+		"L[Checking]",
+		"$1,258.37",
+	}
+
+	for _, l := range lines {
+		err := tx.parseInvestmentTransactionField(l, Config{})
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, "BuyX", tx.Action())
+	assert.Equal(t, "Acme Corp", tx.Security())
+	assert.Equal(t, dec("101.125"), tx.Price())
+	assert.Equal(t, dec("12.3456"), tx.Quantity())
+	assert.Equal(t, NewDecimal(995, 2), tx.Commission())
+	assert.Equal(t, "Bought shares", tx.Payee())
+	assert.Equal(t, "[Checking]", tx.TransferAccount())
+	assert.Equal(t, NewDecimal(125837, 2), tx.TransferAmount())
+}
+
+func TestInvestmentTransactionField(t *testing.T) {
+	tx := &investmentTransaction{}
+	const memo = "memo"
+
+	err := tx.parseInvestmentTransactionField("M"+memo, Config{})
+	require.NoError(t, err)
+
+	assert.Equal(t, memo, tx.Memo())
+}
+
+func TestBadInvestmentPrice(t *testing.T) {
+	tx := &investmentTransaction{}
+	err := tx.parseInvestmentTransactionField("Iabc", Config{})
+	assert.Error(t, err)
+}
+
+func TestBadInvestmentLine(t *testing.T) {
+	tx := &investmentTransaction{}
+	err := tx.parseInvestmentTransactionField("Z1234", Config{})
+
+	_, ok := err.(UnsupportedFieldError)
+	assert.True(t, ok)
+}
+
+func TestInvestmentEmptyLine(t *testing.T) {
+	tx := &investmentTransaction{}
+	err := tx.parseInvestmentTransactionField("", Config{})
+	assert.Error(t, err)
+}
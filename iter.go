@@ -0,0 +1,100 @@
+//   Copyright 2018 Duncan Jones
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package qif
+
+import "iter"
+
+// All implements Reader.All.
+func (r *reader) All() iter.Seq2[Transaction, error] {
+	return records[Transaction](r.readTransaction)
+}
+
+// Records implements Reader.Records.
+func (r *reader) Records() iter.Seq2[Record, error] {
+	return records[Record](r.ReadRecord)
+}
+
+// BankingTransactions implements Reader.BankingTransactions.
+func (r *reader) BankingTransactions() iter.Seq2[BankingTransaction, error] {
+	return records[BankingTransaction](r.readTransaction)
+}
+
+// InvestmentTransactions implements Reader.InvestmentTransactions.
+func (r *reader) InvestmentTransactions() iter.Seq2[InvestmentTransaction,
+	error] {
+	return records[InvestmentTransaction](r.readTransaction)
+}
+
+// MemorizedTransactions implements Reader.MemorizedTransactions.
+func (r *reader) MemorizedTransactions() iter.Seq2[MemorizedTransaction,
+	error] {
+	return records[MemorizedTransaction](r.ReadRecord)
+}
+
+// Accounts implements Reader.Accounts.
+func (r *reader) Accounts() iter.Seq2[Account, error] {
+	return records[Account](r.ReadRecord)
+}
+
+// Categories implements Reader.Categories.
+func (r *reader) Categories() iter.Seq2[Category, error] {
+	return records[Category](r.ReadRecord)
+}
+
+// Classes implements Reader.Classes.
+func (r *reader) Classes() iter.Seq2[Class, error] {
+	return records[Class](r.ReadRecord)
+}
+
+// Securities implements Reader.Securities.
+func (r *reader) Securities() iter.Seq2[Security, error] {
+	return records[Security](r.ReadRecord)
+}
+
+// Prices implements Reader.Prices.
+func (r *reader) Prices() iter.Seq2[Price, error] {
+	return records[Price](r.ReadRecord)
+}
+
+// records returns an iterator over the remaining records of type T, as
+// returned by next, which stops after the first error.
+func records[T interface{}](next func() (Record, error)) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for {
+			rec, err := next()
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+
+			if rec == nil {
+				return
+			}
+
+			if v, ok := rec.(T); ok {
+				if !yield(v, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// readTransaction is like Read, but returns the transaction as a Record for
+// use with records.
+func (r *reader) readTransaction() (Record, error) {
+	return r.Read()
+}
@@ -0,0 +1,139 @@
+//   Copyright 2018 Duncan Jones
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package qif
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestAll(t *testing.T) {
+	input, err := os.Open("testdata/accounts.qif")
+	require.NoError(t, err)
+	defer input.Close()
+
+	var amounts []Decimal
+	for tx, err := range NewReader(input).All() {
+		require.NoError(t, err)
+		amounts = append(amounts, tx.Amount())
+	}
+
+	assert.Equal(t, []Decimal{dec("-25.00"), dec("1500.00"), dec("1010.00")},
+		amounts)
+}
+
+func TestAllBreak(t *testing.T) {
+	input, err := os.Open("testdata/accounts.qif")
+	require.NoError(t, err)
+	defer input.Close()
+
+	r := NewReader(input)
+	for tx, err := range r.All() {
+		require.NoError(t, err)
+		assert.Equal(t, dec("-25.00"), tx.Amount())
+		break
+	}
+
+	// The remaining input can still be read
+	txs, err := r.ReadAll()
+	require.NoError(t, err)
+	assert.Len(t, txs, 2)
+}
+
+func TestAllError(t *testing.T) {
+	input := strings.Join([]string{
+		bankHeader,
+		"T-12.99",
+		recordEnd,
+		"Dbad",
+		recordEnd,
+		"T-1.00",
+		recordEnd,
+	}, "\n")
+
+	var count int
+	var errs []error
+	for tx, err := range NewReader(strings.NewReader(input)).All() {
+		if err != nil {
+			assert.Nil(t, tx)
+			errs = append(errs, err)
+			continue
+		}
+		count++
+	}
+
+	assert.Equal(t, 1, count)
+	require.Len(t, errs, 1)
+	assert.IsType(t, &ParseError{}, errs[0])
+}
+
+func TestTypedIterators(t *testing.T) {
+	input, err := os.Open("testdata/accounts.qif")
+	require.NoError(t, err)
+	defer input.Close()
+
+	var names []string
+	for a, err := range NewReader(input).Accounts() {
+		require.NoError(t, err)
+		names = append(names, a.Name())
+	}
+	assert.Equal(t, []string{"Checking", "Brokerage", "Checking", "Brokerage"},
+		names)
+
+	_, err = input.Seek(0, 0)
+	require.NoError(t, err)
+
+	var securities []string
+	for tx, err := range NewReader(input).InvestmentTransactions() {
+		require.NoError(t, err)
+		securities = append(securities, tx.Security())
+	}
+	assert.Equal(t, []string{"Acme Corp"}, securities)
+}
+
+func TestBankingTransactionsExcludesMemorized(t *testing.T) {
+	input := strings.Join([]string{
+		memorizedHeader,
+		"KP",
+		"T-12.99",
+		recordEnd,
+		bankHeader,
+		"T-1.00",
+		recordEnd,
+	}, "\n")
+
+	var banking, memorized, all int
+	for _, err := range NewReader(strings.NewReader(input)).
+		BankingTransactions() {
+		require.NoError(t, err)
+		banking++
+	}
+	for _, err := range NewReader(strings.NewReader(input)).
+		MemorizedTransactions() {
+		require.NoError(t, err)
+		memorized++
+	}
+	for _, err := range NewReader(strings.NewReader(input)).Records() {
+		require.NoError(t, err)
+		all++
+	}
+
+	assert.Equal(t, 1, banking)
+	assert.Equal(t, 1, memorized)
+	assert.Equal(t, 2, all)
+}
@@ -0,0 +1,294 @@
+//   Copyright 2018 Duncan Jones
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package qif
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// MemorizedType indicates the kind of transaction that was memorized.
+type MemorizedType int
+
+const (
+	UnknownMemorizedType MemorizedType = iota
+	MemorizedCheck
+	MemorizedDeposit
+	MemorizedPayment
+	MemorizedInvestment
+	MemorizedElectronicPayee
+)
+
+// A MemorizedTransaction is a transaction template from a memorized
+// transaction list (i.e. the Memorized section type). In addition to the
+// banking fields, it may contain amortization details for loan payments, or
+// security details for memorized investment transactions.
+type MemorizedTransaction interface {
+	BankingTransaction
+
+	// Type indicates the kind of transaction that was memorized. The value
+	// will be UnknownMemorizedType if the transaction data did not specify a
+	// value for this field.
+	Type() MemorizedType
+
+	// FirstPaymentDate is the date of the first loan payment.
+	FirstPaymentDate() time.Time
+
+	// TotalYears is the total number of years over which the loan is repaid.
+	TotalYears() int
+
+	// PaymentsMade is the number of loan payments already made.
+	PaymentsMade() int
+
+	// PeriodsPerYear is the number of loan payments per year.
+	PeriodsPerYear() int
+
+	// InterestRate is the loan interest rate, as a percentage.
+	InterestRate() Decimal
+
+	// CurrentBalance stores the current loan balance, with the number of
+	// decimal places used by Currency.
+	CurrentBalance() Decimal
+
+	// OriginalAmount stores the original loan amount, with the number of
+	// decimal places used by Currency.
+	OriginalAmount() Decimal
+
+	// Security is the name of the security affected by a memorized investment
+	// transaction.
+	Security() string
+
+	// Price is the price per share of the security, with the decimal places
+	// given in the input data.
+	Price() Decimal
+
+	// Quantity is the number of shares involved in the transaction, with the
+	// decimal places given in the input data.
+	Quantity() Decimal
+
+	// Commission stores the cost of the transaction, with the number of
+	// decimal places used by Currency.
+	Commission() Decimal
+}
+
+type memorizedTransaction struct {
+	bankingTransaction
+	memorizedType    MemorizedType
+	firstPaymentDate time.Time
+	totalYears       int
+	paymentsMade     int
+	periodsPerYear   int
+	interestRate     Decimal
+	currentBalance   Decimal
+	originalAmount   Decimal
+	security         string
+	price            Decimal
+	quantity         Decimal
+	commission       Decimal
+}
+
+func (t *memorizedTransaction) Type() MemorizedType {
+	return t.memorizedType
+}
+
+func (t *memorizedTransaction) FirstPaymentDate() time.Time {
+	return t.firstPaymentDate
+}
+
+func (t *memorizedTransaction) TotalYears() int {
+	return t.totalYears
+}
+
+func (t *memorizedTransaction) PaymentsMade() int {
+	return t.paymentsMade
+}
+
+func (t *memorizedTransaction) PeriodsPerYear() int {
+	return t.periodsPerYear
+}
+
+func (t *memorizedTransaction) InterestRate() Decimal {
+	return t.interestRate
+}
+
+func (t *memorizedTransaction) CurrentBalance() Decimal {
+	return t.currentBalance
+}
+
+func (t *memorizedTransaction) OriginalAmount() Decimal {
+	return t.originalAmount
+}
+
+func (t *memorizedTransaction) Security() string {
+	return t.security
+}
+
+func (t *memorizedTransaction) Price() Decimal {
+	return t.price
+}
+
+func (t *memorizedTransaction) Quantity() Decimal {
+	return t.quantity
+}
+
+func (t *memorizedTransaction) Commission() Decimal {
+	return t.commission
+}
+
+func (t *memorizedTransaction) parseMemorizedTransactionField(line string,
+	config Config) error {
+	if line == "" {
+		return errors.New("line is empty")
+	}
+
+	err := t.parseBankingTransactionField(line, config)
+	if err == nil {
+		// Must have been a field from our embedded struct
+		return nil
+	}
+
+	if _, ok := err.(UnsupportedFieldError); !ok {
+		// An actual error happened
+		return err
+	}
+
+	switch line[0] {
+	case 'K':
+		memorizedType, err := parseMemorizedType(strings.TrimSpace(line[1:]))
+		if err != nil {
+			return errors.Wrap(err, "failed to parse memorized type")
+		}
+		t.memorizedType = memorizedType
+		return nil
+
+	case '1':
+		date, err := parseDate(line[1:], config)
+		if err != nil {
+			return errors.Wrap(err, "failed to parse first payment date")
+		}
+		t.firstPaymentDate = date
+		return nil
+
+	case '2':
+		years, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+		if err != nil {
+			return errors.Wrap(err, "failed to parse total years")
+		}
+		t.totalYears = years
+		return nil
+
+	case '3':
+		payments, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+		if err != nil {
+			return errors.Wrap(err, "failed to parse payments made")
+		}
+		t.paymentsMade = payments
+		return nil
+
+	case '4':
+		periods, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+		if err != nil {
+			return errors.Wrap(err, "failed to parse periods per year")
+		}
+		t.periodsPerYear = periods
+		return nil
+
+	case '5':
+		rate, err := parseNumber(line[1:], config)
+		if err != nil {
+			return errors.Wrap(err, "failed to parse interest rate")
+		}
+		t.interestRate = rate
+		return nil
+
+	case '6':
+		amt, err := parseAmount(line[1:], t.currency, config)
+		if err != nil {
+			return errors.Wrap(err, "failed to parse current balance")
+		}
+		t.currentBalance = amt
+		return nil
+
+	case '7':
+		amt, err := parseAmount(line[1:], t.currency, config)
+		if err != nil {
+			return errors.Wrap(err, "failed to parse original amount")
+		}
+		t.originalAmount = amt
+		return nil
+	}
+
+	return t.parseInvestmentField(line, config)
+}
+
+// parseInvestmentField parses the fields of a memorized investment
+// transaction, which are shared with investment transactions.
+func (t *memorizedTransaction) parseInvestmentField(line string,
+	config Config) error {
+	switch line[0] {
+	case 'Y':
+		t.security = line[1:]
+		return nil
+
+	case 'I':
+		price, err := parseNumber(line[1:], config)
+		if err != nil {
+			return errors.Wrap(err, "failed to parse price")
+		}
+		t.price = price
+		return nil
+
+	case 'Q':
+		qty, err := parseNumber(line[1:], config)
+		if err != nil {
+			return errors.Wrap(err, "failed to parse quantity")
+		}
+		t.quantity = qty
+		return nil
+
+	case 'O':
+		amt, err := parseAmount(line[1:], t.currency, config)
+		if err != nil {
+			return errors.Wrap(err, "failed to parse commission")
+		}
+		t.commission = amt
+		return nil
+
+	default:
+		return UnsupportedFieldError{Line: line}
+	}
+}
+
+func parseMemorizedType(s string) (MemorizedType, error) {
+	switch s {
+	case "C":
+		return MemorizedCheck, nil
+	case "D":
+		return MemorizedDeposit, nil
+	case "P":
+		return MemorizedPayment, nil
+	case "I":
+		return MemorizedInvestment, nil
+	case "E":
+		return MemorizedElectronicPayee, nil
+
+	default:
+		return UnknownMemorizedType, errors.Errorf(
+			`bad memorized type: "%s"`, s)
+	}
+}
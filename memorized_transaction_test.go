@@ -0,0 +1,135 @@
+//   Copyright 2018 Duncan Jones
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package qif
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestMemorizedType(t *testing.T) {
+	vectors := map[string]MemorizedType{
+		"C": MemorizedCheck,
+		"D": MemorizedDeposit,
+		"P": MemorizedPayment,
+		"I": MemorizedInvestment,
+		"E": MemorizedElectronicPayee,
+	}
+
+	for k, v := range vectors {
+		res, err := parseMemorizedType(k)
+		assert.NoError(t, err)
+		assert.Equal(t, v, res)
+	}
+
+	_, err := parseMemorizedType("Z") // not real
+	assert.Error(t, err)
+}
+
+func TestAmortizationFields(t *testing.T) {
+	tx := &memorizedTransaction{}
+
+	lines := []string{
+		"KP",
+		"16/ 1/94",
+		"230",
+		"312",
+		"412",
+		"57.25",
+		"6123,456.78",
+		"7150,000.00",
+	}
+
+	for _, l := range lines {
+		err := tx.parseMemorizedTransactionField(l, Config{})
+		require.NoError(t, err)
+	}
+
+	date, err := time.Parse("1/ 2/06", "6/ 1/94")
+	require.NoError(t, err)
+
+	assert.Equal(t, MemorizedPayment, tx.Type())
+	assert.Equal(t, date, tx.FirstPaymentDate())
+	assert.Equal(t, 30, tx.TotalYears())
+	assert.Equal(t, 12, tx.PaymentsMade())
+	assert.Equal(t, 12, tx.PeriodsPerYear())
+	assert.Equal(t, dec("7.25"), tx.InterestRate())
+	assert.Equal(t, NewDecimal(12345678, 2), tx.CurrentBalance())
+	assert.Equal(t, NewDecimal(15000000, 2), tx.OriginalAmount())
+}
+
+func TestMemorizedBankingFields(t *testing.T) {
+	tx := &memorizedTransaction{}
+
+	for _, l := range []string{"PFred", "T-12.99", "LFood"} {
+		err := tx.parseMemorizedTransactionField(l, Config{})
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, "Fred", tx.Payee())
+	assert.Equal(t, NewDecimal(-1299, 2), tx.Amount())
+	assert.Equal(t, "Food", tx.Category())
+}
+
+func TestMemorizedInvestmentFields(t *testing.T) {
+	tx := &memorizedTransaction{}
+
+	lines := []string{"KI", "PBuy Acme", "YAcme Corp", "I101.125", "Q10",
+		"O9.95", "T-1,021.20"}
+	for _, l := range lines {
+		err := tx.parseMemorizedTransactionField(l, Config{})
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, MemorizedInvestment, tx.Type())
+	assert.Equal(t, "Acme Corp", tx.Security())
+	assert.Equal(t, dec("101.125"), tx.Price())
+	assert.Equal(t, dec("10"), tx.Quantity())
+	assert.Equal(t, dec("9.95"), tx.Commission())
+	assert.Equal(t, dec("-1021.20"), tx.Amount())
+
+	// The fields may come before the type
+	tx = &memorizedTransaction{}
+	for _, l := range []string{"YAcme Corp", "Q10", "KI"} {
+		err := tx.parseMemorizedTransactionField(l, Config{})
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, MemorizedInvestment, tx.Type())
+	assert.Equal(t, "Acme Corp", tx.Security())
+	assert.Equal(t, dec("10"), tx.Quantity())
+}
+
+func TestBadAmortizationField(t *testing.T) {
+	tx := &memorizedTransaction{}
+	err := tx.parseMemorizedTransactionField("2thirty", Config{})
+	assert.Error(t, err)
+}
+
+func TestBadMemorizedLine(t *testing.T) {
+	tx := &memorizedTransaction{}
+	err := tx.parseMemorizedTransactionField("Z1234", Config{})
+
+	_, ok := err.(UnsupportedFieldError)
+	assert.True(t, ok)
+}
+
+func TestMemorizedEmptyLine(t *testing.T) {
+	tx := &memorizedTransaction{}
+	err := tx.parseMemorizedTransactionField("", Config{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,142 @@
+//   Copyright 2018 Duncan Jones
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package qif
+
+import (
+	"strings"
+	"time"
+)
+
+// MonthNames maps lower case month names and abbreviations to months. It is
+// used to parse dates such as "15 März 2024" (see Config.MonthNames).
+type MonthNames map[string]time.Month
+
+// lookup returns the month with the given name, ignoring case. English names
+// are recognised in addition to those in m.
+func (m MonthNames) lookup(name string) (time.Month, bool) {
+	name = strings.ToLower(name)
+
+	if month, ok := m[name]; ok {
+		return month, true
+	}
+
+	month, ok := EnglishMonths[name]
+	return month, ok
+}
+
+var (
+	// EnglishMonths contains English month names. These are always
+	// recognised.
+	EnglishMonths = MonthNames{
+		"january": time.January, "jan": time.January,
+		"february": time.February, "feb": time.February,
+		"march": time.March, "mar": time.March,
+		"april": time.April, "apr": time.April,
+		"may":  time.May,
+		"june": time.June, "jun": time.June,
+		"july": time.July, "jul": time.July,
+		"august": time.August, "aug": time.August,
+		"september": time.September, "sep": time.September,
+		"sept":    time.September,
+		"october": time.October, "oct": time.October,
+		"november": time.November, "nov": time.November,
+		"december": time.December, "dec": time.December,
+	}
+
+	// GermanMonths contains German month names.
+	GermanMonths = MonthNames{
+		"januar": time.January, "jan": time.January,
+		"jänner": time.January, "jän": time.January,
+		"februar": time.February, "feb": time.February,
+		"märz": time.March, "mär": time.March, "maerz": time.March,
+		"april": time.April, "apr": time.April,
+		"mai":  time.May,
+		"juni": time.June, "jun": time.June,
+		"juli": time.July, "jul": time.July,
+		"august": time.August, "aug": time.August,
+		"september": time.September, "sep": time.September,
+		"sept":    time.September,
+		"oktober": time.October, "okt": time.October,
+		"november": time.November, "nov": time.November,
+		"dezember": time.December, "dez": time.December,
+	}
+
+	// FrenchMonths contains French month names.
+	FrenchMonths = MonthNames{
+		"janvier": time.January, "janv": time.January,
+		"février": time.February, "févr": time.February,
+		"fevrier": time.February, "fevr": time.February,
+		"mars":  time.March,
+		"avril": time.April, "avr": time.April,
+		"mai":     time.May,
+		"juin":    time.June,
+		"juillet": time.July, "juil": time.July,
+		"août": time.August, "aout": time.August,
+		"septembre": time.September, "sept": time.September,
+		"octobre": time.October, "oct": time.October,
+		"novembre": time.November, "nov": time.November,
+		"décembre": time.December, "déc": time.December,
+		"decembre": time.December, "dec": time.December,
+	}
+
+	// SpanishMonths contains Spanish month names.
+	SpanishMonths = MonthNames{
+		"enero": time.January, "ene": time.January,
+		"febrero": time.February, "feb": time.February,
+		"marzo": time.March, "mar": time.March,
+		"abril": time.April, "abr": time.April,
+		"mayo": time.May, "may": time.May,
+		"junio": time.June, "jun": time.June,
+		"julio": time.July, "jul": time.July,
+		"agosto": time.August, "ago": time.August,
+		"septiembre": time.September, "sep": time.September,
+		"setiembre": time.September, "set": time.September,
+		"octubre": time.October, "oct": time.October,
+		"noviembre": time.November, "nov": time.November,
+		"diciembre": time.December, "dic": time.December,
+	}
+
+	// ItalianMonths contains Italian month names.
+	ItalianMonths = MonthNames{
+		"gennaio": time.January, "gen": time.January,
+		"febbraio": time.February, "feb": time.February,
+		"marzo": time.March, "mar": time.March,
+		"aprile": time.April, "apr": time.April,
+		"maggio": time.May, "mag": time.May,
+		"giugno": time.June, "giu": time.June,
+		"luglio": time.July, "lug": time.July,
+		"agosto": time.August, "ago": time.August,
+		"settembre": time.September, "set": time.September,
+		"ottobre": time.October, "ott": time.October,
+		"novembre": time.November, "nov": time.November,
+		"dicembre": time.December, "dic": time.December,
+	}
+
+	// DutchMonths contains Dutch month names.
+	DutchMonths = MonthNames{
+		"januari": time.January, "jan": time.January,
+		"februari": time.February, "feb": time.February,
+		"maart": time.March, "mrt": time.March,
+		"april": time.April, "apr": time.April,
+		"mei":  time.May,
+		"juni": time.June, "jun": time.June,
+		"juli": time.July, "jul": time.July,
+		"augustus": time.August, "aug": time.August,
+		"september": time.September, "sep": time.September,
+		"oktober": time.October, "okt": time.October,
+		"november": time.November, "nov": time.November,
+		"december": time.December, "dec": time.December,
+	}
+)
@@ -16,30 +16,159 @@ package qif
 
 import (
 	"bufio"
+	"bytes"
+	"context"
 	"io"
+	"iter"
+	"strings"
+	"unicode"
 
 	"github.com/pkg/errors"
 )
 
 const (
-	bankHeader = "!Type:Bank"
-	cashHeader = "!Type:Cash"
-	cardHeader = "!Type:CCard"
-	recordEnd  = "^"
+	bankHeader       = "!Type:Bank"
+	cashHeader       = "!Type:Cash"
+	cardHeader       = "!Type:CCard"
+	investmentHeader = "!Type:Invst"
+	assetHeader      = "!Type:Oth A"
+	liabilityHeader  = "!Type:Oth L"
+	accountHeader    = "!Account"
+	categoryHeader   = "!Type:Cat"
+	classHeader      = "!Type:Class"
+	memorizedHeader  = "!Type:Memorized"
+	securityHeader   = "!Type:Security"
+	pricesHeader     = "!Type:Prices"
+	autoSwitchOption = "!Option:AutoSwitch"
+	autoSwitchClear  = "!Clear:AutoSwitch"
+	typePrefix       = "!Type:"
+	optionPrefix     = "!Option:"
+	clearPrefix      = "!Clear:"
+	recordEnd        = "^"
 )
 
+// defaultMaxLineLength is used when Config.MaxLineLength is zero.
+const defaultMaxLineLength = 1 << 20
+
+// registerHeaders maps the headers of transaction registers to their account
+// types.
+var registerHeaders = map[string]AccountType{
+	bankHeader:       BankAccount,
+	cashHeader:       CashAccount,
+	cardHeader:       CreditCardAccount,
+	investmentHeader: InvestmentAccount,
+	assetHeader:      AssetAccount,
+	liabilityHeader:  LiabilityAccount,
+}
+
+// knownHeaders contains the headers and options understood by the reader, in
+// the form used to compare them with the header field.
+var knownHeaders = []string{
+	bankHeader, cashHeader, cardHeader, investmentHeader, assetHeader,
+	liabilityHeader, accountHeader, categoryHeader, classHeader,
+	memorizedHeader, securityHeader, pricesHeader, autoSwitchOption,
+	autoSwitchClear,
+}
+
+// A Record is a single entry read from QIF data. It holds one of the record
+// types: a Transaction (or one of the more specific transaction interfaces),
+// an Account, a Category, a Class, a Security or a Price.
+type Record interface{}
+
 // A Reader consumes QIF data and returns parsed transactions.
 type Reader interface {
 
 	// Read returns the next transaction from the input data. Returns nil if
-	// the end of the input has been reached. If the input ends without a
-	// terminating '^' symbol, the result will be the transaction data read
-	// thus far and a RecordEndError.
+	// the end of the input has been reached. All errors are returned as a
+	// *ParseError, which records the position of the problem. If the input
+	// ends without a terminating '^' symbol, the ParseError wraps a
+	// RecordEndError containing the transaction data read thus far.
+	//
+	// The input may contain several sections, each introduced by a header
+	// line. Records that are not transactions are skipped, although accounts
+	// are attached to the transactions that follow them (see
+	// Transaction.Account). Memorized transactions are templates rather than
+	// register entries, so they are also skipped; use ReadRecord or
+	// MemorizedTransactions to read them.
 	Read() (Transaction, error)
 
 	// ReadAll returns all the remaining transactions from the input data. It
 	// returns the same errors as Read.
 	ReadAll() ([]Transaction, error)
+
+	// ReadContext is like Read, but stops if ctx is cancelled. The context is
+	// checked between records (and between lines while detecting the date
+	// order), and a ParseError wrapping ctx.Err() is returned if it is done.
+	// The reader is left at the start of the next record, so reading can
+	// resume with a new context.
+	ReadContext(ctx context.Context) (Transaction, error)
+
+	// ReadAllContext is like ReadAll, but stops if ctx is cancelled, as
+	// described for ReadContext. The transactions read before the
+	// cancellation are discarded.
+	ReadAllContext(ctx context.Context) ([]Transaction, error)
+
+	// ReadRecord returns the next record of any type from the input data.
+	// Returns nil if the end of the input has been reached. It returns the
+	// same errors as Read.
+	ReadRecord() (Record, error)
+
+	// ReadAllRecords returns all the remaining records from the input data.
+	// It returns the same errors as Read.
+	ReadAllRecords() ([]Record, error)
+
+	// All returns an iterator over the remaining transactions in the input
+	// data, for use in a range loop. Records are read as the loop progresses,
+	// so the input is never held in memory. Errors are those returned by
+	// Read, and the iteration stops after an error. Breaking out of the loop
+	// leaves the rest of the input unread, ready for further calls.
+	All() iter.Seq2[Transaction, error]
+
+	// Records returns an iterator over the remaining records of any type. It
+	// behaves like All.
+	Records() iter.Seq2[Record, error]
+
+	// BankingTransactions returns an iterator over the remaining banking
+	// transactions, skipping other records. Like Read, it does not include
+	// memorized transactions. It behaves like All.
+	BankingTransactions() iter.Seq2[BankingTransaction, error]
+
+	// InvestmentTransactions returns an iterator over the remaining
+	// investment transactions, skipping other records. It behaves like All.
+	InvestmentTransactions() iter.Seq2[InvestmentTransaction, error]
+
+	// MemorizedTransactions returns an iterator over the remaining memorized
+	// transactions, skipping other records. It behaves like All.
+	MemorizedTransactions() iter.Seq2[MemorizedTransaction, error]
+
+	// Accounts returns an iterator over the remaining accounts, skipping
+	// other records. It behaves like All.
+	Accounts() iter.Seq2[Account, error]
+
+	// Categories returns an iterator over the remaining categories, skipping
+	// other records. It behaves like All.
+	Categories() iter.Seq2[Category, error]
+
+	// Classes returns an iterator over the remaining classes, skipping other
+	// records. It behaves like All.
+	Classes() iter.Seq2[Class, error]
+
+	// Securities returns an iterator over the remaining securities, skipping
+	// other records. It behaves like All.
+	Securities() iter.Seq2[Security, error]
+
+	// Prices returns an iterator over the remaining prices, skipping other
+	// records. It behaves like All.
+	Prices() iter.Seq2[Price, error]
+
+	// Diagnostics returns the errors found in records that were skipped
+	// because Config.SkipBadRecords is set. It is empty otherwise.
+	Diagnostics() []*ParseError
+
+	// DayFirst reports whether dates are being interpreted as dd/mm rather
+	// than mm/dd. If Config.DetectDateOrder is set, this reflects the detected
+	// order once the first record has been read.
+	DayFirst() bool
 }
 
 // reader implements Reader. Construct using NewReader or NewReaderWithConfig.
@@ -48,12 +177,62 @@ type reader struct {
 	// in scans the input.
 	in *bufio.Scanner
 
+	// encoding is the encoding of the input, which is used to convert the
+	// lines returned by in to UTF-8 and to count the bytes consumed.
+	encoding Encoding
+
+	// src holds the input until the date order has been detected, if
+	// Config.DetectDateOrder is set. It is nil once scanning has begun.
+	src io.Reader
+
 	// config defines the behaviour of the reader.
 	config Config
 
-	// headerParsed is true if the header line has been read from the input
+	// headerParsed is true if a header line has been read from the input
 	// data.
 	headerParsed bool
+
+	// header is the most recent section header read from the input data. It
+	// determines the type of record parsed by Read.
+	header string
+
+	// accountList is true while reading a list of accounts bracketed by
+	// AutoSwitch options. Accounts in such a list do not own the transactions
+	// that follow.
+	accountList bool
+
+	// account is the account that owns subsequent transactions, or nil if no
+	// account has been read.
+	account Account
+
+	// line is the number of lines read from the input data.
+	line int
+
+	// offset is the byte offset of the start of the current line.
+	offset int64
+
+	// consumed is the number of bytes consumed by the scanner, including line
+	// endings.
+	consumed int64
+
+	// records is the number of records read from the input data, including
+	// any that were skipped.
+	records int
+
+	// skipSection is true while ignoring a section with an unsupported header.
+	skipSection bool
+
+	// diagnostics contains the errors found in skipped records.
+	diagnostics []*ParseError
+
+	// detectErr is the error from detecting the date order, if
+	// Config.DetectDateOrder is set. It is returned by every read, as the
+	// dates cannot be parsed reliably.
+	detectErr error
+
+	// ctx is checked for cancellation between records. It is nil unless a
+	// method taking a context is in progress.
+	ctx context.Context
 }
 
 // NewReader creates a new Reader with a default configuration (see
@@ -64,67 +243,489 @@ func NewReader(r io.Reader) *reader {
 
 // NewReaderWithConfig creates a new Reader with the specified configuration.
 func NewReaderWithConfig(r io.Reader, config Config) *reader {
-	return &reader{
-		in:     bufio.NewScanner(r),
+	rd := &reader{
 		config: config,
 	}
+
+	if config.DetectDateOrder {
+		// The input is scanned when the first record is read
+		rd.src = r
+	} else {
+		rd.setInput(r)
+	}
+
+	return rd
 }
 
-// parseHeader reads the first line of the input and validates the header. An
-// error is returned if the input is empty or the wrong type of header is found.
-func (r *reader) parseHeader() error {
-	if !r.in.Scan() {
-		if err := r.in.Err(); err != nil {
-			return err
+// setInput prepares the reader to scan the input, detecting its encoding if
+// required.
+func (r *reader) setInput(in io.Reader) {
+	decoded, encoding, bomLen := decodeInput(in, r.config.Encoding)
+	r.encoding = encoding
+	r.consumed = int64(bomLen)
+
+	r.in = newLineScanner(decoded, r.config.MaxLineLength)
+	r.in.Split(r.scanLines)
+}
+
+// scanLines wraps splitLines to count the bytes of input consumed by the
+// scanner.
+func (r *reader) scanLines(data []byte, atEOF bool) (int, []byte, error) {
+	advance, token, err := splitLines(data, atEOF,
+		maxLineLength(r.config.MaxLineLength))
+	r.consumed += rawLength(data[:advance], r.encoding)
+	return advance, token, err
+}
+
+// maxLineLength returns the line length limit given by Config.MaxLineLength.
+func maxLineLength(configured int) int {
+	if configured <= 0 {
+		return defaultMaxLineLength
+	}
+
+	return configured
+}
+
+// newLineScanner returns a scanner that splits the input into lines of at
+// most maxLength bytes (see Config.MaxLineLength).
+func newLineScanner(in io.Reader, maxLength int) *bufio.Scanner {
+	maxLength = maxLineLength(maxLength)
+
+	scanner := bufio.NewScanner(in)
+
+	// Allow room for the line ending, so that splitLines reports long lines
+	scanner.Buffer(nil, maxLength+2)
+	scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		return splitLines(data, atEOF, maxLength)
+	})
+
+	return scanner
+}
+
+// splitLines is a bufio.SplitFunc that accepts "\n", "\r\n" and "\r" line
+// endings. It returns ErrLineTooLong if a line exceeds maxLength bytes.
+func splitLines(data []byte, atEOF bool, maxLength int) (int, []byte,
+	error) {
+	i := bytes.IndexAny(data, "\r\n")
+	if i < 0 {
+		switch {
+		case len(data) > maxLength:
+			return 0, nil, ErrLineTooLong
+		case atEOF && len(data) > 0:
+			return len(data), data, nil
+		default:
+			return 0, nil, nil
+		}
+	}
+
+	if i > maxLength {
+		return 0, nil, ErrLineTooLong
+	}
+
+	if data[i] == '\r' {
+		if i+1 == len(data) && !atEOF {
+			// Wait to see if this is a Windows line ending
+			return 0, nil, nil
 		}
 
-		return errors.New("file header not found")
+		if i+1 < len(data) && data[i+1] == '\n' {
+			return i + 2, data[:i], nil
+		}
+	}
+
+	return i + 1, data[:i], nil
+}
+
+// normaliseLine removes whitespace before the field code of a line, keeping
+// any at the end of the value. Headers and record ends have no value, so they
+// are trimmed on both sides, and headers are given the capitalisation used by
+// the header constants.
+func normaliseLine(line string) string {
+	line = strings.TrimLeftFunc(line, unicode.IsSpace)
+
+	trimmed := strings.TrimRightFunc(line, unicode.IsSpace)
+	if trimmed == recordEnd {
+		return trimmed
+	}
+
+	if !strings.HasPrefix(trimmed, "!") {
+		return line
+	}
+
+	line = trimmed
+
+	for _, header := range knownHeaders {
+		if strings.EqualFold(line, header) {
+			return header
+		}
+	}
+
+	return line
+}
+
+// text returns the current line of input, converted to UTF-8.
+func (r *reader) text() string {
+	return decodeLine(r.in.Bytes(), r.encoding)
+}
+
+// scan advances to the next line of input, recording its position.
+func (r *reader) scan() bool {
+	r.offset = r.consumed
+
+	if !r.in.Scan() {
+		return false
 	}
 
-	switch r.in.Text() {
-	case bankHeader, cashHeader, cardHeader:
+	r.line++
+	return true
+}
+
+// newParseError returns a ParseError for the current line.
+func (r *reader) newParseError(line string, err error) *ParseError {
+	pe := &ParseError{
+		Line:   r.line,
+		Offset: r.offset,
+		Record: r.records,
+		Text:   line,
+		Err:    err,
+	}
+
+	if line != "" && line[0] != '!' && line != recordEnd {
+		pe.Field = line[0]
+	}
+
+	return pe
+}
+
+// parseHeader processes a line starting with '!'. Section headers change the
+// type of record being read, while options are recorded or ignored. An error
+// is returned if the header type is not supported.
+func (r *reader) parseHeader(line string) error {
+	if _, ok := registerHeaders[line]; ok {
+		r.header = line
 		r.headerParsed = true
 		return nil
+	}
+
+	switch line {
+	case accountHeader, categoryHeader, classHeader, memorizedHeader,
+		securityHeader, pricesHeader:
+		r.header = line
+
+	case autoSwitchOption:
+		r.accountList = true
+
+	case autoSwitchClear:
+		r.accountList = false
 
 	default:
-		return errors.Errorf("unsupported header type '%s'", r.in.Text())
+		// Other options (e.g. "!Option:MDY") do not affect parsing
+		if !hasPrefixFold(line, optionPrefix) &&
+			!hasPrefixFold(line, clearPrefix) {
+			return errors.Errorf("unsupported header type '%s'", line)
+		}
+	}
+
+	r.headerParsed = true
+	return nil
+}
+
+// hasPrefixFold reports whether s begins with prefix, ignoring case.
+func hasPrefixFold(s, prefix string) bool {
+	return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+}
+
+// newRecord returns an empty record of the type indicated by the current
+// header, along with the function used to parse its fields.
+func (r *reader) newRecord() (Record, func(string, Config) error) {
+	switch r.header {
+	case accountHeader:
+		a := &account{currency: r.config.Currency}
+		return a, a.parseAccountField
+
+	case categoryHeader:
+		c := &category{}
+		return c, c.parseCategoryField
+
+	case classHeader:
+		c := &class{}
+		return c, c.parseClassField
+
+	case securityHeader:
+		s := &security{}
+		return s, s.parseSecurityField
+
+	case pricesHeader:
+		p := &price{}
+		return p, p.parsePriceField
+
+	case memorizedHeader:
+		tx := &memorizedTransaction{}
+		tx.currency = r.config.Currency
+		return tx, tx.parseMemorizedTransactionField
+
+	case investmentHeader:
+		tx := &investmentTransaction{}
+		tx.account = r.account
+		tx.accountType = InvestmentAccount
+		tx.currency = r.config.currency(r.account)
+		return tx, tx.parseInvestmentTransactionField
+
+	default:
+		tx := &bankingTransaction{}
+		tx.account = r.account
+		tx.accountType = registerHeaders[r.header]
+		tx.currency = r.config.currency(r.account)
+		return tx, tx.parseBankingTransactionField
 	}
 }
 
 // Read implements Reader.Read.
 func (r *reader) Read() (Transaction, error) {
-
-	if !r.headerParsed {
-		err := r.parseHeader()
+	for {
+		rec, err := r.ReadRecord()
 		if err != nil {
-			return nil, errors.Wrap(err, "failed to parse file header")
+			return nil, err
+		}
+
+		if rec == nil {
+			return nil, nil
+		}
+
+		if _, ok := rec.(MemorizedTransaction); ok {
+			continue
+		}
+
+		if tx, ok := rec.(Transaction); ok {
+			return tx, nil
+		}
+	}
+}
+
+// ReadContext implements Reader.ReadContext.
+func (r *reader) ReadContext(ctx context.Context) (Transaction, error) {
+	r.ctx = ctx
+	defer func() { r.ctx = nil }()
+
+	return r.Read()
+}
+
+// ReadRecord implements Reader.ReadRecord.
+func (r *reader) ReadRecord() (Record, error) {
+	rec, err := r.readRecord()
+
+	if r.config.Progress != nil {
+		r.config.Progress(r.consumed, r.records)
+	}
+
+	return rec, err
+}
+
+// readRecord reads the next record, as described for Reader.ReadRecord.
+func (r *reader) readRecord() (Record, error) {
+	if r.src != nil {
+		if err := r.detectDateOrder(); err != nil {
+			return nil, err
 		}
 	}
 
-	// Only one type supported at the moment
-	tx := &bankingTransaction{}
-	data := false
+	if r.detectErr != nil {
+		return nil, r.detectErr
+	}
+
+	var (
+		rec        Record
+		parseField func(string, Config) error
+
+		// skipping is true while discarding the remainder of a bad record
+		skipping bool
+
+		// lines holds the raw lines of the record, if they are being kept
+		lines []string
+		keep  = r.config.KeepUnknownFields
+	)
+
+	for {
+		if parseField == nil && !skipping && r.ctx != nil {
+			// Only stop between records, so that reading can resume
+			if err := r.ctx.Err(); err != nil {
+				r.offset = r.consumed
+				pe := r.newParseError("", err)
+
+				// The next line has not been read
+				pe.Line++
+				return nil, pe
+			}
+		}
+
+		if !r.scan() {
+			break
+		}
+
+		raw := r.text()
+		line := normaliseLine(raw)
+		if line == "" {
+			if parseField != nil && keep {
+				lines = append(lines, raw)
+			}
+			continue
+		}
+
+		if strings.HasPrefix(line, "!") {
+			if parseField != nil {
+				err := r.fail(r.newParseError(line, errors.Errorf(
+					"unexpected header '%s' before end of record", line)))
+				if err != nil {
+					return nil, err
+				}
+
+				// Abandon the record and process the header
+				rec, parseField = nil, nil
+				r.records++
+			}
+
+			skipping = false
+			r.skipSection = false
+
+			err := r.parseHeader(line)
+			if err != nil {
+				err = r.fail(r.newParseError(line,
+					errors.Wrap(err, "failed to parse header")))
+				if err != nil {
+					return nil, err
+				}
+
+				// Ignore everything until the next header
+				r.skipSection = true
+			}
+			continue
+		}
+
+		if r.skipSection {
+			continue
+		}
+
+		if !r.headerParsed {
+			return nil, r.newParseError(line, errors.Wrap(
+				errors.New("file header not found"),
+				"failed to parse file header"))
+		}
+
+		if skipping {
+			if line == recordEnd {
+				skipping = false
+				r.records++
+			}
+			continue
+		}
 
-	for r.in.Scan() {
-		data = true
-		line := r.in.Text()
+		if parseField == nil {
+			// Start of a new record
+			rec, parseField = r.newRecord()
+			lines = nil
+		}
+
+		if keep {
+			lines = append(lines, raw)
+			rec.(rawRecord).raw().lines = lines
+		}
 
 		if line == recordEnd {
-			return tx, nil
+			if acct, ok := rec.(Account); ok && !r.accountList {
+				r.account = acct
+			}
+
+			err := r.finishRecord(rec)
+			r.records++
+			if err != nil {
+				err = r.fail(r.newParseError(line, err))
+				if err != nil {
+					return nil, err
+				}
+
+				rec, parseField = nil, nil
+				continue
+			}
+
+			return rec, nil
+		}
+
+		err := parseField(line, r.config)
+		if _, ok := err.(UnsupportedFieldError); ok {
+			err = r.handleUnknownField(rec, line)
 		}
 
-		err := tx.parseBankingTransactionField(r.in.Text(), r.config)
 		if err != nil {
-			return nil, err
+			err = r.fail(r.newParseError(line, err))
+			if err != nil {
+				return nil, err
+			}
+
+			// Resynchronise at the end of the record
+			rec, parseField = nil, nil
+			skipping = true
 		}
 	}
 
-	if !data {
+	if err := r.in.Err(); err != nil {
+		pe := r.newParseError("", err)
+		if err == ErrLineTooLong {
+			// The scanner stopped at the start of the long line
+			pe.Line++
+		}
+		return nil, pe
+	}
+
+	if !r.headerParsed && !r.skipSection {
+		return nil, r.newParseError("", errors.Wrap(
+			errors.New("file header not found"),
+			"failed to parse file header"))
+	}
+
+	if parseField == nil {
 		// We were at the end of the file
 		return nil, nil
 	}
 
-	return nil, RecordEndError{Incomplete: tx}
+	tx, _ := rec.(Transaction)
+	return nil, r.fail(r.newParseError("", RecordEndError{Incomplete: tx}))
+}
+
+// finishRecord applies any processing required once a record is complete.
+func (r *reader) finishRecord(rec Record) error {
+	if !r.config.BalanceSplits {
+		return nil
+	}
+
+	switch tx := rec.(type) {
+	case *bankingTransaction:
+		return tx.balanceSplits()
+	case *memorizedTransaction:
+		return tx.balanceSplits()
+	}
+
+	return nil
+}
+
+// fail returns err, unless the reader is configured to skip bad records. In
+// that case, err is recorded as a diagnostic and nil is returned.
+func (r *reader) fail(err *ParseError) error {
+	if !r.config.SkipBadRecords {
+		return err
+	}
+
+	r.diagnostics = append(r.diagnostics, err)
+	return nil
+}
+
+// DayFirst implements Reader.DayFirst.
+func (r *reader) DayFirst() bool {
+	return r.config.DayFirst
+}
+
+// Diagnostics implements Reader.Diagnostics.
+func (r *reader) Diagnostics() []*ParseError {
+	return r.diagnostics
 }
 
 // ReadAll implements Reader.ReadAll.
@@ -146,3 +747,31 @@ func (r *reader) ReadAll() ([]Transaction, error) {
 
 	return result, nil
 }
+
+// ReadAllContext implements Reader.ReadAllContext.
+func (r *reader) ReadAllContext(ctx context.Context) ([]Transaction, error) {
+	r.ctx = ctx
+	defer func() { r.ctx = nil }()
+
+	return r.ReadAll()
+}
+
+// ReadAllRecords implements Reader.ReadAllRecords.
+func (r *reader) ReadAllRecords() ([]Record, error) {
+	var result []Record
+
+	for {
+		rec, err := r.ReadRecord()
+		if err != nil {
+			return nil, err
+		}
+
+		if rec == nil {
+			break
+		}
+
+		result = append(result, rec)
+	}
+
+	return result, nil
+}
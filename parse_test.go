@@ -15,11 +15,16 @@
 package qif
 
 import (
+	"context"
+	"errors"
+	pkgerrors "github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"io"
 	"os"
 	"strings"
 	"testing"
+	"testing/iotest"
 	"time"
 )
 
@@ -35,11 +40,11 @@ func TestUnexpectedEOF(t *testing.T) {
 	tx, err := r.ReadAll()
 	assert.Nil(t, tx)
 
-	e, ok := err.(RecordEndError)
-	require.True(t, ok)
+	var e RecordEndError
+	require.True(t, errors.As(err, &e))
 
 	assert.Equal(t, "memo", e.Incomplete.Memo())
-	assert.Equal(t, -9950, e.Incomplete.Amount())
+	assert.Equal(t, NewDecimal(-9950, 2), e.Incomplete.Amount())
 }
 
 func TestBadHeader(t *testing.T) {
@@ -74,22 +79,24 @@ func TestReadOne(t *testing.T) {
 	assert.NoError(t, err)
 
 	assert.Equal(t, "memo", tx.Memo())
-	assert.Equal(t, -9950, tx.Amount())
+	assert.Equal(t, NewDecimal(-9950, 2), tx.Amount())
 
 	tx, err = r.Read()
 	assert.NoError(t, err)
 
 	btx := tx.(BankingTransaction)
 	assert.Equal(t, []string{"address1", "address2"}, btx.Address())
-	assert.Equal(t, 12300, btx.Amount())
+	assert.Equal(t, NewDecimal(12300, 2), btx.Amount())
 }
 
 func strptr(s string) *string {
 	return &s
 }
 
-func intptr(i int) *int {
-	return &i
+// amtptr returns a pointer to an amount with two decimal places.
+func amtptr(coef int64) *Decimal {
+	d := NewDecimal(coef, 2)
+	return &d
 }
 
 func TestSpecExample1(t *testing.T) {
@@ -108,20 +115,22 @@ func TestSpecExample1(t *testing.T) {
 		payee:    "Bank Of Mortgage",
 		category: "[linda]",
 		splits: []Split{
-			{Category: strptr("[linda]"), Amount: intptr(-25364)},
-			{Category: strptr("Mort Int"), Amount: intptr(-74636)},
+			{Category: strptr("[linda]"), Amount: amtptr(-25364)},
+			{Category: strptr("Mort Int"), Amount: amtptr(-74636)},
 		},
 	}
 	expected1.date, err = time.Parse("1/ 2/06", "6/ 1/94")
 	require.NoError(t, err)
-	expected1.amount = -100000
+	expected1.amount = NewDecimal(-100000, 2)
+	expected1.accountType = BankAccount
 
 	expected2 := &bankingTransaction{
 		payee: "Deposit",
 	}
 	expected2.date, err = time.Parse("1/ 2/06", "6/ 2/94")
 	require.NoError(t, err)
-	expected2.amount = 7500
+	expected2.amount = NewDecimal(7500, 2)
+	expected2.accountType = BankAccount
 
 	expected3 := &bankingTransaction{
 		payee:    "Anthony Hopkins",
@@ -130,8 +139,9 @@ func TestSpecExample1(t *testing.T) {
 	}
 	expected3.date, err = time.Parse("1/ 2/06", "6/ 3/94")
 	require.NoError(t, err)
-	expected3.amount = -1000
+	expected3.amount = NewDecimal(-1000, 2)
 	expected3.memo = "Film"
+	expected3.accountType = BankAccount
 
 	expected := []Transaction{expected1, expected2, expected3}
 
@@ -141,3 +151,890 @@ func TestSpecExample1(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, expected, txs)
 }
+
+func TestInvestmentFile(t *testing.T) {
+	input, err := os.Open("testdata/investment.qif")
+	require.NoError(t, err)
+	defer input.Close()
+
+	txs, err := NewReader(input).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, txs, 3)
+
+	buy, ok := txs[0].(InvestmentTransaction)
+	require.True(t, ok)
+	assert.Equal(t, "Buy", buy.Action())
+	assert.Equal(t, "Acme Corp", buy.Security())
+	assert.Equal(t, dec("101.125"), buy.Price())
+	assert.Equal(t, dec("10"), buy.Quantity())
+	assert.Equal(t, NewDecimal(101125, 2), buy.Amount())
+	assert.Equal(t, NewDecimal(995, 2), buy.Commission())
+
+	div := txs[1].(InvestmentTransaction)
+	assert.Equal(t, "Div", div.Action())
+	assert.Equal(t, "Quarterly dividend", div.Memo())
+
+	sell := txs[2].(InvestmentTransaction)
+	assert.Equal(t, "SellX", sell.Action())
+	assert.Equal(t, "[Checking]", sell.TransferAccount())
+	assert.Equal(t, NewDecimal(55250, 2), sell.TransferAmount())
+}
+
+func TestMultipleSections(t *testing.T) {
+	input, err := os.Open("testdata/accounts.qif")
+	require.NoError(t, err)
+	defer input.Close()
+
+	txs, err := NewReader(input).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, txs, 3)
+
+	for _, tx := range txs[:2] {
+		_, ok := tx.(BankingTransaction)
+		require.True(t, ok)
+		require.NotNil(t, tx.Account())
+		assert.Equal(t, "Checking", tx.Account().Name())
+		assert.Equal(t, "Bank", tx.Account().Type())
+		assert.Equal(t, "Main account", tx.Account().Description())
+	}
+
+	inv, ok := txs[2].(InvestmentTransaction)
+	require.True(t, ok)
+	require.NotNil(t, inv.Account())
+	assert.Equal(t, "Brokerage", inv.Account().Name())
+	assert.Equal(t, "Buy", inv.Action())
+}
+
+func TestNoAccount(t *testing.T) {
+	inputData := strings.Join([]string{
+		bankHeader,
+		"T-99.50",
+		recordEnd,
+	}, "\n")
+
+	tx, err := NewReader(strings.NewReader(inputData)).Read()
+	require.NoError(t, err)
+	assert.Nil(t, tx.Account())
+}
+
+func TestHeaderInsideRecord(t *testing.T) {
+	inputData := strings.Join([]string{
+		bankHeader,
+		"T-99.50",
+		cashHeader,
+		"T-1.00",
+		recordEnd,
+	}, "\n")
+
+	_, err := NewReader(strings.NewReader(inputData)).ReadAll()
+	assert.Error(t, err)
+}
+
+func TestMissingHeader(t *testing.T) {
+	inputData := strings.Join([]string{
+		"T-99.50",
+		recordEnd,
+	}, "\n")
+
+	_, err := NewReader(strings.NewReader(inputData)).ReadAll()
+	assert.Error(t, err)
+
+	_, err = NewReader(strings.NewReader("")).ReadAll()
+	assert.Error(t, err)
+}
+
+func TestReadRecords(t *testing.T) {
+	input, err := os.Open("testdata/categories.qif")
+	require.NoError(t, err)
+	defer input.Close()
+
+	recs, err := NewReader(input).ReadAllRecords()
+	require.NoError(t, err)
+	require.Len(t, recs, 4)
+
+	var names []string
+	for _, rec := range recs[:3] {
+		c, ok := rec.(Category)
+		require.True(t, ok)
+		names = append(names, c.Name())
+	}
+	assert.Equal(t, []string{"Entertain", "Mort Int", "Salary"}, names)
+
+	mort := recs[1].(Category)
+	assert.Equal(t, "Mortgage interest", mort.Description())
+	assert.True(t, mort.TaxRelated())
+	assert.Equal(t, "Schedule A", mort.TaxSchedule())
+
+	tx, ok := recs[3].(BankingTransaction)
+	require.True(t, ok)
+	assert.Equal(t, "Entertain", tx.Category())
+}
+
+func TestReadSkipsNonTransactions(t *testing.T) {
+	input, err := os.Open("testdata/categories.qif")
+	require.NoError(t, err)
+	defer input.Close()
+
+	txs, err := NewReader(input).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, txs, 1)
+	assert.Equal(t, "Anthony Hopkins", txs[0].(BankingTransaction).Payee())
+}
+
+func TestReadAccountRecords(t *testing.T) {
+	input, err := os.Open("testdata/accounts.qif")
+	require.NoError(t, err)
+	defer input.Close()
+
+	recs, err := NewReader(input).ReadAllRecords()
+	require.NoError(t, err)
+
+	var accounts []string
+	for _, rec := range recs {
+		if a, ok := rec.(Account); ok {
+			accounts = append(accounts, a.Name())
+		}
+	}
+	assert.Equal(t, []string{"Checking", "Brokerage", "Checking", "Brokerage"},
+		accounts)
+}
+
+func TestReadClasses(t *testing.T) {
+	inputData := strings.Join([]string{
+		classHeader,
+		"NBusiness",
+		"DWork expenses",
+		recordEnd,
+		"NPersonal",
+		recordEnd,
+		bankHeader,
+		"T-10.00",
+		"LAuto:Fuel/Business",
+		recordEnd,
+	}, "\n")
+
+	recs, err := NewReader(strings.NewReader(inputData)).ReadAllRecords()
+	require.NoError(t, err)
+	require.Len(t, recs, 3)
+
+	assert.Equal(t, "Business", recs[0].(Class).Name())
+	assert.Equal(t, "Work expenses", recs[0].(Class).Description())
+	assert.Equal(t, "Personal", recs[1].(Class).Name())
+
+	tx := recs[2].(BankingTransaction)
+	assert.Equal(t, "Auto:Fuel", tx.Category())
+	assert.Equal(t, "Business", tx.Class())
+}
+
+func TestMemorizedFile(t *testing.T) {
+	input, err := os.Open("testdata/memorized.qif")
+	require.NoError(t, err)
+	defer input.Close()
+
+	var txs []MemorizedTransaction
+	for tx, err := range NewReader(input).MemorizedTransactions() {
+		require.NoError(t, err)
+		txs = append(txs, tx)
+	}
+	require.Len(t, txs, 3)
+
+	mort := txs[0]
+	assert.Equal(t, MemorizedCheck, mort.Type())
+	assert.Equal(t, "Bank Of Mortgage", mort.Payee())
+	assert.Len(t, mort.Splits(), 2)
+	assert.Equal(t, dec("7.25"), mort.InterestRate())
+
+	dep := txs[1]
+	assert.Equal(t, MemorizedDeposit, dep.Type())
+	assert.Equal(t, NewDecimal(7500, 2), dep.Amount())
+
+	inv := txs[2]
+	assert.Equal(t, MemorizedInvestment, inv.Type())
+	assert.Equal(t, "Acme Corp", inv.Security())
+	assert.Equal(t, dec("10"), inv.Quantity())
+}
+
+func TestReadSkipsMemorized(t *testing.T) {
+	inputData := strings.Join([]string{
+		memorizedHeader,
+		"KP",
+		"T-12.99",
+		recordEnd,
+		bankHeader,
+		"T-1.00",
+		recordEnd,
+	}, "\n")
+
+	txs, err := NewReader(strings.NewReader(inputData)).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, txs, 1)
+	assert.Equal(t, dec("-1.00"), txs[0].Amount())
+
+	recs, err := NewReader(strings.NewReader(inputData)).ReadAllRecords()
+	require.NoError(t, err)
+	require.Len(t, recs, 2)
+	assert.Equal(t, dec("-12.99"), recs[0].(MemorizedTransaction).Amount())
+}
+
+func TestSecuritiesAndPrices(t *testing.T) {
+	input, err := os.Open("testdata/prices.qif")
+	require.NoError(t, err)
+	defer input.Close()
+
+	recs, err := NewReader(input).ReadAllRecords()
+	require.NoError(t, err)
+	require.Len(t, recs, 5)
+
+	acme, ok := recs[0].(Security)
+	require.True(t, ok)
+	assert.Equal(t, "Acme Corp", acme.Name())
+	assert.Equal(t, "ACME", acme.Symbol())
+	assert.Equal(t, "Mutual Fund", recs[1].(Security).Type())
+
+	var prices []Decimal
+	for _, rec := range recs[2:] {
+		p, ok := rec.(Price)
+		require.True(t, ok)
+		prices = append(prices, p.Price())
+	}
+	assert.Equal(t, []Decimal{dec("101.125"), dec("102.50"), dec("9.8765")},
+		prices)
+	assert.Equal(t, "WDGT", recs[4].(Price).Symbol())
+}
+
+func TestOtherAccountTypes(t *testing.T) {
+	inputData := strings.Join([]string{
+		assetHeader,
+		"D1/ 1/18",
+		"T25,000.00",
+		"PRevaluation",
+		recordEnd,
+		liabilityHeader,
+		"D1/ 1/18",
+		"T-1,250.00",
+		"PLoan payment",
+		recordEnd,
+		cardHeader,
+		"T-10.00",
+		recordEnd,
+	}, "\n")
+
+	txs, err := NewReader(strings.NewReader(inputData)).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, txs, 3)
+
+	asset := txs[0].(BankingTransaction)
+	assert.Equal(t, AssetAccount, asset.AccountType())
+	assert.Equal(t, NewDecimal(2500000, 2), asset.Amount())
+
+	loan := txs[1].(BankingTransaction)
+	assert.Equal(t, LiabilityAccount, loan.AccountType())
+	assert.Equal(t, "Loan payment", loan.Payee())
+
+	assert.Equal(t, CreditCardAccount, txs[2].(BankingTransaction).AccountType())
+}
+
+func TestAccountTypeChanges(t *testing.T) {
+	inputData := strings.Join([]string{
+		bankHeader,
+		"T-10.00",
+		recordEnd,
+		cardHeader,
+		"T-20.00",
+		recordEnd,
+		investmentHeader,
+		"NBuy",
+		"T30.00",
+		recordEnd,
+		memorizedHeader,
+		"KC",
+		"T-40.00",
+		recordEnd,
+		cashHeader,
+		"T-50.00",
+		recordEnd,
+	}, "\n")
+
+	txs, err := NewReader(strings.NewReader(inputData)).ReadAll()
+	require.NoError(t, err)
+
+	var types []AccountType
+	for _, tx := range txs {
+		types = append(types, tx.AccountType())
+	}
+
+	assert.Equal(t, []AccountType{BankAccount, CreditCardAccount,
+		InvestmentAccount, CashAccount}, types)
+}
+
+func TestAccountCurrencies(t *testing.T) {
+	input, err := os.Open("testdata/currencies.qif")
+	require.NoError(t, err)
+	defer input.Close()
+
+	config := Config{
+		Currency: "USD",
+		AccountCurrencies: map[string]string{
+			"Yen Account":   "JPY",
+			"Dinar Account": "KWD",
+		},
+	}
+
+	txs, err := NewReaderWithConfig(input, config).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, txs, 4)
+
+	assert.Equal(t, "JPY", txs[0].Currency())
+	assert.Equal(t, "JPY", txs[0].Account().Currency())
+	assert.Equal(t, dec("150000"), txs[0].Account().Balance())
+	assert.Equal(t, dec("-2500"), txs[0].Amount())
+	assert.Equal(t, dec("150000"), txs[1].Amount())
+
+	dinar := txs[2].(BankingTransaction)
+	assert.Equal(t, "KWD", dinar.Currency())
+	assert.Equal(t, dec("-12.345"), dinar.Amount())
+	assert.Equal(t, dec("-0.345"), *dinar.Splits()[1].Amount)
+
+	assert.Equal(t, "USD", txs[3].Currency())
+	assert.Equal(t, dec("-10.50"), txs[3].Amount())
+}
+
+func TestAmountTooPreciseForCurrency(t *testing.T) {
+	inputData := strings.Join([]string{
+		bankHeader,
+		"T-25.00",
+		recordEnd,
+	}, "\n")
+
+	_, err := NewReaderWithConfig(strings.NewReader(inputData),
+		Config{Currency: "JPY"}).ReadAll()
+	assert.Error(t, err)
+}
+
+func TestBalanceSplitsOption(t *testing.T) {
+	inputData := strings.Join([]string{
+		bankHeader,
+		"T-100.00",
+		"SFood",
+		"$-60.00",
+		recordEnd,
+		memorizedHeader,
+		"KP",
+		"T-50.00",
+		"SRent",
+		"$-50.00",
+		recordEnd,
+	}, "\n")
+
+	recs, err := NewReaderWithConfig(strings.NewReader(inputData),
+		Config{BalanceSplits: true}).ReadAllRecords()
+	require.NoError(t, err)
+	require.Len(t, recs, 2)
+
+	bank := recs[0].(BankingTransaction)
+	require.Len(t, bank.Splits(), 2)
+	assert.Nil(t, bank.Splits()[1].Category)
+	assert.Equal(t, dec("-40.00"), *bank.Splits()[1].Amount)
+
+	assert.Len(t, recs[1].(MemorizedTransaction).Splits(), 1)
+
+	// Without the option, the splits are left as they are
+	txs, err := NewReader(strings.NewReader(inputData)).ReadAll()
+	require.NoError(t, err)
+	assert.Len(t, txs[0].(BankingTransaction).Splits(), 1)
+	assert.Error(t, CheckSplits(txs[0].(BankingTransaction)))
+}
+
+func TestParseErrorPosition(t *testing.T) {
+	inputData := strings.Join([]string{
+		bankHeader,
+		"D1/ 1/18",
+		"T-10.00",
+		recordEnd,
+		"D1/ 2/18",
+		"Tbad",
+		recordEnd,
+	}, "\r\n")
+
+	r := NewReader(strings.NewReader(inputData))
+
+	_, err := r.Read()
+	require.NoError(t, err)
+
+	_, err = r.Read()
+
+	var pe *ParseError
+	require.True(t, errors.As(err, &pe))
+	assert.Equal(t, 6, pe.Line)
+	assert.Equal(t, int64(len(bankHeader)+34), pe.Offset)
+	assert.Equal(t, 1, pe.Record)
+	assert.Equal(t, byte('T'), pe.Field)
+	assert.Equal(t, "Tbad", pe.Text)
+	assert.Contains(t, pe.Error(), "line 6, field 'T'")
+}
+
+func TestParseErrorHeader(t *testing.T) {
+	inputData := strings.Join([]string{
+		bankHeader,
+		"T-10.00",
+		recordEnd,
+		"!Type:Bonk",
+	}, "\n")
+
+	_, err := NewReader(strings.NewReader(inputData)).ReadAll()
+
+	var pe *ParseError
+	require.True(t, errors.As(err, &pe))
+	assert.Equal(t, 4, pe.Line)
+	assert.Equal(t, 1, pe.Record)
+	assert.Equal(t, byte(0), pe.Field)
+	assert.Equal(t, "!Type:Bonk", pe.Text)
+}
+
+func TestParseErrorUnexpectedEOF(t *testing.T) {
+	inputData := strings.Join([]string{
+		bankHeader,
+		"T-10.00",
+	}, "\n")
+
+	_, err := NewReader(strings.NewReader(inputData)).Read()
+
+	var pe *ParseError
+	require.True(t, errors.As(err, &pe))
+	assert.Equal(t, 2, pe.Line)
+	assert.Equal(t, 0, pe.Record)
+
+	_, ok := pe.Err.(RecordEndError)
+	assert.True(t, ok)
+}
+
+func TestParseErrorChain(t *testing.T) {
+	inputData := strings.Join([]string{
+		bankHeader,
+		"T9999999999999999999999",
+		recordEnd,
+	}, "\n")
+
+	_, err := NewReader(strings.NewReader(inputData)).Read()
+	require.Error(t, err)
+
+	// Errors wrapped while parsing a field can still be found
+	assert.True(t, errors.Is(err, ErrDecimalOverflow))
+	assert.Equal(t, ErrDecimalOverflow, pkgerrors.Cause(err))
+
+	var pe *ParseError
+	require.True(t, errors.As(err, &pe))
+	assert.Equal(t, byte('T'), pe.Field)
+}
+
+func TestSkipBadRecords(t *testing.T) {
+	inputData := strings.Join([]string{
+		bankHeader,
+		"D1/ 1/18",
+		"T-10.00",
+		recordEnd,
+		"D99/99/99",
+		"T-20.00",
+		"PSkipped",
+		recordEnd,
+		"D1/ 3/18",
+		"T-30.00",
+		recordEnd,
+		"!Type:Bonk",
+		"T-40.00",
+		recordEnd,
+		cashHeader,
+		"T-50.00",
+		"T-bad",
+		cardHeader,
+		"T-60.00",
+		recordEnd,
+		"T-70.00",
+	}, "\n")
+
+	r := NewReaderWithConfig(strings.NewReader(inputData),
+		Config{SkipBadRecords: true})
+
+	txs, err := r.ReadAll()
+	require.NoError(t, err)
+
+	var amounts []int64
+	for _, tx := range txs {
+		amounts = append(amounts, tx.Amount().Coefficient())
+	}
+	assert.Equal(t, []int64{-1000, -3000, -6000}, amounts)
+
+	diags := r.Diagnostics()
+	require.Len(t, diags, 4)
+
+	assert.Equal(t, 5, diags[0].Line)
+	assert.Equal(t, byte('D'), diags[0].Field)
+	assert.Equal(t, 1, diags[0].Record)
+
+	assert.Equal(t, 12, diags[1].Line)
+	assert.Equal(t, "!Type:Bonk", diags[1].Text)
+
+	assert.Equal(t, 17, diags[2].Line)
+	assert.Equal(t, "T-bad", diags[2].Text)
+
+	_, ok := diags[3].Err.(RecordEndError)
+	assert.True(t, ok)
+}
+
+func TestSkipRecordWithHeader(t *testing.T) {
+	inputData := strings.Join([]string{
+		bankHeader,
+		"T-10.00",
+		cardHeader,
+		"T-20.00",
+		recordEnd,
+	}, "\n")
+
+	r := NewReaderWithConfig(strings.NewReader(inputData),
+		Config{SkipBadRecords: true})
+
+	txs, err := r.ReadAll()
+	require.NoError(t, err)
+	require.Len(t, txs, 1)
+	assert.Equal(t, CreditCardAccount, txs[0].AccountType())
+
+	require.Len(t, r.Diagnostics(), 1)
+	assert.Equal(t, 3, r.Diagnostics()[0].Line)
+	assert.Equal(t, 0, r.Diagnostics()[0].Record)
+}
+
+func TestStrictModeHasNoDiagnostics(t *testing.T) {
+	inputData := strings.Join([]string{
+		bankHeader,
+		"Tbad",
+		recordEnd,
+	}, "\n")
+
+	r := NewReader(strings.NewReader(inputData))
+	_, err := r.ReadAll()
+	assert.Error(t, err)
+	assert.Empty(t, r.Diagnostics())
+}
+
+func TestLineEndings(t *testing.T) {
+	lines := []string{
+		bankHeader,
+		"D03/01/2018",
+		"T-12.99",
+		"PFred",
+		recordEnd,
+		"D03/02/2018",
+		"T-1.00",
+		recordEnd,
+	}
+
+	expected, err := NewReader(strings.NewReader(
+		strings.Join(lines, "\n"))).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, expected, 2)
+
+	for _, ending := range []string{"\r\n", "\r"} {
+		input := strings.Join(lines, ending) + ending
+
+		// Read a byte at a time, so that line endings are split between reads
+		r := NewReader(iotest.OneByteReader(strings.NewReader(input)))
+		txs, err := r.ReadAll()
+		require.NoErrorf(t, err, "error processing %q", ending)
+		assert.Equalf(t, expected, txs, "error processing %q", ending)
+	}
+
+	// Mixed line endings
+	input := bankHeader + "\r\nT-12.99\rPFred\n^"
+	tx, err := NewReader(strings.NewReader(input)).Read()
+	require.NoError(t, err)
+	assert.Equal(t, "Fred", tx.(BankingTransaction).Payee())
+}
+
+func TestLinePositionsWithCRLF(t *testing.T) {
+	input := bankHeader + "\r\n\r\nDbad\r\n^\r\n"
+
+	_, err := NewReader(strings.NewReader(input)).Read()
+	require.Error(t, err)
+
+	var pe *ParseError
+	require.True(t, errors.As(err, &pe))
+	assert.Equal(t, 3, pe.Line)
+	assert.Equal(t, int64(len(bankHeader)+4), pe.Offset)
+}
+
+func TestWhitespaceAndBlankLines(t *testing.T) {
+	inputData := strings.Join([]string{
+		"",
+		"  " + bankHeader + "  ",
+		"",
+		"D03/01/2018 ",
+		"\tT-12.99",
+		"   ",
+		"PFred  ",
+		"^ ",
+		"",
+		"",
+		"T-1.00",
+		" ^",
+		"",
+	}, "\n")
+
+	txs, err := NewReader(strings.NewReader(inputData)).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, txs, 2)
+
+	// Trailing whitespace is part of the value
+	assert.Equal(t, "Fred  ", txs[0].(BankingTransaction).Payee())
+	assert.Equal(t, dec("-12.99"), txs[0].Amount())
+	assert.Equal(t, dec("-1.00"), txs[1].Amount())
+}
+
+func TestTrailingWhitespaceInValues(t *testing.T) {
+	inputData := strings.Join([]string{
+		memorizedHeader + " ",
+		"  KP ",
+		"C* ",
+		"T-10.00 ",
+		"Mmemo with space ",
+		"SFood",
+		"$-10.00",
+		"%100% ",
+		"^  ",
+		pricesHeader,
+		`"ACME",101.125,"1/ 2/18" `,
+		recordEnd,
+	}, "\n")
+
+	recs, err := NewReader(strings.NewReader(inputData)).ReadAllRecords()
+	require.NoError(t, err)
+	require.Len(t, recs, 2)
+
+	tx := recs[0].(MemorizedTransaction)
+	assert.Equal(t, MemorizedPayment, tx.Type())
+	assert.EqualValues(t, Cleared, tx.Status())
+	assert.Equal(t, "memo with space ", tx.Memo())
+	assert.Equal(t, dec("100"), *tx.Splits()[0].Percent)
+	assert.Equal(t, dec("101.125"), recs[1].(Price).Price())
+}
+
+func TestCaseInsensitiveHeaders(t *testing.T) {
+	inputData := strings.Join([]string{
+		"!option:autoswitch",
+		"!ACCOUNT",
+		"NChecking",
+		"TBank",
+		recordEnd,
+		"!clear:AUTOSWITCH",
+		"!account",
+		"NBrokerage",
+		"TInvst",
+		recordEnd,
+		"!TYPE:INVST",
+		"NBuy",
+		"T30.00",
+		recordEnd,
+		"!type:oth a",
+		"T-10.00",
+		recordEnd,
+		"!option:mdy",
+	}, "\n")
+
+	txs, err := NewReader(strings.NewReader(inputData)).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, txs, 2)
+
+	assert.Equal(t, InvestmentAccount, txs[0].AccountType())
+	assert.Equal(t, "Buy", txs[0].(InvestmentTransaction).Action())
+	assert.Equal(t, "Brokerage", txs[0].Account().Name())
+	assert.Equal(t, AssetAccount, txs[1].AccountType())
+}
+
+func TestMaxLineLength(t *testing.T) {
+	memo := strings.Repeat("x", 100000)
+	inputData := strings.Join([]string{
+		bankHeader,
+		"T-12.99",
+		"M" + memo,
+		recordEnd,
+	}, "\n")
+
+	// Lines longer than bufio.Scanner's default limit are accepted
+	tx, err := NewReader(strings.NewReader(inputData)).Read()
+	require.NoError(t, err)
+	assert.Equal(t, memo, tx.Memo())
+
+	r := NewReaderWithConfig(strings.NewReader(inputData),
+		Config{MaxLineLength: 1000})
+	_, err = r.Read()
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrLineTooLong))
+
+	var pe *ParseError
+	require.True(t, errors.As(err, &pe))
+	assert.Equal(t, 3, pe.Line)
+	assert.Equal(t, int64(len(bankHeader)+9), pe.Offset)
+
+	// The limit excludes the line ending
+	inputData = bankHeader + "\r\nT-12.99\r\n^\r\n"
+	_, err = NewReaderWithConfig(strings.NewReader(inputData),
+		Config{MaxLineLength: len(bankHeader)}).ReadAll()
+	assert.NoError(t, err)
+
+	_, err = NewReaderWithConfig(strings.NewReader(inputData),
+		Config{MaxLineLength: len(bankHeader) - 1}).ReadAll()
+	assert.True(t, errors.Is(err, ErrLineTooLong))
+}
+
+func TestReadContext(t *testing.T) {
+	inputData := strings.Join([]string{
+		bankHeader,
+		"T-12.99",
+		recordEnd,
+		"T-1.00",
+		recordEnd,
+	}, "\n")
+
+	r := NewReader(strings.NewReader(inputData))
+	tx, err := r.ReadContext(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, dec("-12.99"), tx.Amount())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = r.ReadContext(ctx)
+	assert.True(t, errors.Is(err, context.Canceled))
+
+	var pe *ParseError
+	require.True(t, errors.As(err, &pe))
+	assert.Equal(t, 4, pe.Line)
+	assert.Equal(t, int64(len(bankHeader)+11), pe.Offset)
+
+	_, err = r.ReadAllContext(ctx)
+	assert.True(t, errors.Is(err, context.Canceled))
+
+	// Reading resumes at the next record
+	txs, err := r.ReadAllContext(context.Background())
+	require.NoError(t, err)
+	require.Len(t, txs, 1)
+	assert.Equal(t, dec("-1.00"), txs[0].Amount())
+}
+
+func TestReadAllContextCancelled(t *testing.T) {
+	inputData := strings.Join([]string{
+		bankHeader,
+		"T-12.99",
+		recordEnd,
+		"T-1.00",
+		recordEnd,
+	}, "\n")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Cancel once the first record has been read
+	config := Config{Progress: func(bytes int64, records int) {
+		if records == 1 {
+			cancel()
+		}
+	}}
+
+	r := NewReaderWithConfig(strings.NewReader(inputData), config)
+	txs, err := r.ReadAllContext(ctx)
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.IsType(t, &ParseError{}, err)
+	assert.Nil(t, txs)
+}
+
+func TestReadContextDetectDateOrder(t *testing.T) {
+	inputData := strings.Join([]string{
+		bankHeader,
+		"D25/12/18",
+		recordEnd,
+	}, "\n")
+
+	for _, in := range []io.Reader{
+		strings.NewReader(inputData),
+		iotest.OneByteReader(strings.NewReader(inputData)),
+	} {
+		r := NewReaderWithConfig(in, Config{DetectDateOrder: true})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		// The date order scan stops, and is repeated by the next read
+		_, err := r.ReadContext(ctx)
+		assert.True(t, errors.Is(err, context.Canceled))
+
+		tx, err := r.ReadContext(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, time.December, tx.Date().Month())
+	}
+}
+
+// cancelAfter is a context that is cancelled once Err has been called n
+// times.
+type cancelAfter struct {
+	context.Context
+	n int
+}
+
+func (c *cancelAfter) Err() error {
+	if c.n--; c.n > 0 {
+		return nil
+	}
+	return context.Canceled
+}
+
+func TestReadContextDetectDateOrderPosition(t *testing.T) {
+	inputData := strings.Join([]string{
+		bankHeader,
+		"D25/12/18",
+		recordEnd,
+	}, "\n")
+
+	r := NewReaderWithConfig(strings.NewReader(inputData),
+		Config{DetectDateOrder: true})
+
+	// The scan is cancelled before the third line
+	ctx := &cancelAfter{Context: context.Background(), n: 3}
+	_, err := r.ReadContext(ctx)
+
+	var pe *ParseError
+	require.True(t, errors.As(err, &pe))
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.Equal(t, 3, pe.Line)
+	assert.EqualValues(t, len(bankHeader)+len("D25/12/18")+2, pe.Offset)
+}
+
+func TestProgress(t *testing.T) {
+	inputData := strings.Join([]string{
+		accountHeader,
+		"NChecking",
+		recordEnd,
+		bankHeader,
+		"T-12.99",
+		recordEnd,
+	}, "\n")
+
+	type report struct {
+		bytes   int64
+		records int
+	}
+
+	var reports []report
+	config := Config{Progress: func(bytes int64, records int) {
+		reports = append(reports, report{bytes, records})
+	}}
+
+	txs, err := NewReaderWithConfig(strings.NewReader(inputData),
+		config).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, txs, 1)
+
+	assert.Equal(t, []report{
+		{int64(len(accountHeader) + 13), 1},
+		{int64(len(inputData)), 2},
+		{int64(len(inputData)), 2},
+	}, reports)
+}
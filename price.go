@@ -0,0 +1,99 @@
+//   Copyright 2018 Duncan Jones
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package qif
+
+import (
+	"encoding/csv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// A Price is an entry in a price history (i.e. the Prices section type). Each
+// entry records the price of a security on a given date.
+type Price interface {
+
+	// Symbol is the ticker symbol of the security.
+	Symbol() string
+
+	// Price is the price per share of the security, with the decimal places
+	// given in the input data.
+	Price() Decimal
+
+	// Date contains the year, month and day of the price. All other fields are
+	// zero.
+	Date() time.Time
+
+	// RawRecord gives access to the original data of the price.
+	RawRecord
+}
+
+type price struct {
+	rawData
+	symbol string
+	price  Decimal
+	date   time.Time
+}
+
+func (p *price) Symbol() string {
+	return p.symbol
+}
+
+func (p *price) Price() Decimal {
+	return p.price
+}
+
+func (p *price) Date() time.Time {
+	return p.date
+}
+
+// parsePriceField parses a price line. Unlike other records, prices are not
+// made up of fields. Instead, each record is a single line of comma-separated
+// values in the form "symbol",price,"date".
+func (p *price) parsePriceField(line string, config Config) error {
+	if line == "" {
+		return errors.New("line is empty")
+	}
+
+	if p.symbol != "" {
+		return errors.Errorf("unexpected second price line '%s'", line)
+	}
+
+	r := csv.NewReader(strings.NewReader(strings.TrimSpace(line)))
+	r.LazyQuotes = true
+	values, err := r.Read()
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse price line '%s'", line)
+	}
+
+	if len(values) != 3 {
+		return errors.Errorf("expected 3 values in price line '%s'", line)
+	}
+
+	p.symbol = values[0]
+
+	p.price, err = parseNumber(values[1], config)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse price")
+	}
+
+	p.date, err = parseDate(values[2], config)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse price date")
+	}
+
+	return nil
+}
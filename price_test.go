@@ -0,0 +1,61 @@
+//   Copyright 2018 Duncan Jones
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package qif
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestPriceLine(t *testing.T) {
+	p := &price{}
+
+	err := p.parsePriceField(`"ACME",101.125,"12/31/17"`, Config{})
+	require.NoError(t, err)
+
+	date, err := time.Parse("01/02/06", "12/31/17")
+	require.NoError(t, err)
+
+	assert.Equal(t, "ACME", p.Symbol())
+	assert.Equal(t, dec("101.125"), p.Price())
+	assert.Equal(t, date, p.Date())
+}
+
+func TestBadPriceLines(t *testing.T) {
+	badLines := []string{
+		"",
+		`"ACME",101.125`,
+		`"ACME",abc,"12/31/17"`,
+		`"ACME",101.125,"not a date"`,
+	}
+
+	for _, l := range badLines {
+		p := &price{}
+		err := p.parsePriceField(l, Config{})
+		assert.Errorf(t, err, "error processing '%s'", l)
+	}
+}
+
+func TestSecondPriceLine(t *testing.T) {
+	p := &price{}
+
+	err := p.parsePriceField(`"ACME",101.125,"12/31/17"`, Config{})
+	require.NoError(t, err)
+
+	err = p.parsePriceField(`"ACME",102.5,"1/1/18"`, Config{})
+	assert.Error(t, err)
+}
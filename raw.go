@@ -0,0 +1,65 @@
+//   Copyright 2018 Duncan Jones
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package qif
+
+// A Field is a single line of a record, made up of a field code and a value.
+type Field struct {
+
+	// Code is the first character of the line (e.g. 'F').
+	Code byte
+
+	// Value is the remainder of the line.
+	Value string
+}
+
+// A RawRecord provides access to the original data of a record. All record
+// types implement it, but the data is only retained if
+// Config.KeepUnknownFields is set.
+type RawRecord interface {
+
+	// Extra contains the fields with codes the reader does not understand,
+	// in the order they appeared. The writer outputs them after the other
+	// fields of a record.
+	Extra() []Field
+
+	// RawLines contains the lines of the input that made up the record,
+	// including the terminating '^' line. Lines are converted to UTF-8 but are
+	// otherwise unchanged.
+	RawLines() []string
+}
+
+// rawData implements RawRecord. It is embedded in every record type.
+type rawData struct {
+	extra []Field
+	lines []string
+}
+
+func (d *rawData) Extra() []Field {
+	return d.extra
+}
+
+func (d *rawData) RawLines() []string {
+	return d.lines
+}
+
+// raw gives the reader access to the embedded rawData.
+func (d *rawData) raw() *rawData {
+	return d
+}
+
+// rawRecord is implemented by all record types.
+type rawRecord interface {
+	raw() *rawData
+}
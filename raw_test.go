@@ -0,0 +1,123 @@
+//   Copyright 2018 Duncan Jones
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package qif
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+// unknownFieldsInput contains an account and a transaction with fields the
+// reader does not understand.
+var unknownFieldsInput = strings.Join([]string{
+	accountHeader,
+	"NChecking",
+	"TBank",
+	"Zcustom",
+	recordEnd,
+	bankHeader,
+	"D03/01/2018",
+	"Freimbursable",
+	"T-12.99",
+	"",
+	"  G2018-01-03 ",
+	"PFred",
+	recordEnd,
+}, "\n")
+
+func TestKeepUnknownFields(t *testing.T) {
+	r := NewReaderWithConfig(strings.NewReader(unknownFieldsInput),
+		Config{KeepUnknownFields: true})
+	recs, err := r.ReadAllRecords()
+	require.NoError(t, err)
+	require.Len(t, recs, 2)
+
+	acct := recs[0].(Account)
+	assert.Equal(t, []Field{{Code: 'Z', Value: "custom"}}, acct.Extra())
+	assert.Equal(t, []string{"NChecking", "TBank", "Zcustom", recordEnd},
+		acct.RawLines())
+
+	tx := recs[1].(BankingTransaction)
+	assert.Equal(t, "Fred", tx.Payee())
+	assert.Equal(t, []Field{
+		{Code: 'F', Value: "reimbursable"},
+		{Code: 'G', Value: "2018-01-03 "},
+	}, tx.Extra())
+	assert.Equal(t, []string{"D03/01/2018", "Freimbursable", "T-12.99", "",
+		"  G2018-01-03 ", "PFred", recordEnd}, tx.RawLines())
+}
+
+func TestUnknownFieldsNotKept(t *testing.T) {
+	_, err := NewReader(strings.NewReader(unknownFieldsInput)).ReadAll()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot process line 'Zcustom'")
+}
+
+func TestUnknownFieldsSkippedWhenLenient(t *testing.T) {
+	r := NewReaderWithConfig(strings.NewReader(unknownFieldsInput),
+		Config{SkipBadRecords: true})
+	recs, err := r.ReadAllRecords()
+	require.NoError(t, err)
+	assert.Empty(t, recs)
+}
+
+func TestRawDataNotRetainedByDefault(t *testing.T) {
+	input := strings.Join([]string{
+		bankHeader,
+		"D03/01/2018",
+		"T-12.99",
+		recordEnd,
+	}, "\n")
+
+	tx, err := NewReader(strings.NewReader(input)).Read()
+	require.NoError(t, err)
+	assert.Empty(t, tx.Extra())
+	assert.Empty(t, tx.RawLines())
+}
+
+func TestBadFieldValueStillFails(t *testing.T) {
+	input := strings.Join([]string{
+		bankHeader,
+		"Dnot a date",
+		recordEnd,
+	}, "\n")
+
+	_, err := NewReaderWithConfig(strings.NewReader(input),
+		Config{KeepUnknownFields: true}).Read()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to parse date")
+}
+
+func TestWriteUnknownFields(t *testing.T) {
+	config := Config{KeepUnknownFields: true}
+	original, err := NewReaderWithConfig(strings.NewReader(unknownFieldsInput),
+		config).ReadAll()
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, NewWriter(&buf).WriteAll(original))
+	assert.Contains(t, buf.String(), "\nZcustom\n^\n")
+	assert.Contains(t, buf.String(), "\nFreimbursable\nG2018-01-03 \n^\n")
+
+	result, err := NewReaderWithConfig(&buf, config).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, result, 1)
+	assert.Equal(t, original[0].Extra(), result[0].Extra())
+	assert.Equal(t, original[0].Account().Extra(),
+		result[0].Account().Extra())
+}
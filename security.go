@@ -0,0 +1,89 @@
+//   Copyright 2018 Duncan Jones
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package qif
+
+import "github.com/pkg/errors"
+
+// A Security is an entry in a security list (i.e. the Security section type).
+type Security interface {
+
+	// Name of the security. Investment transactions refer to securities by
+	// this name.
+	Name() string
+
+	// Symbol is the ticker symbol of the security.
+	Symbol() string
+
+	// Type of the security, such as "Stock", "Bond" or "Mutual Fund".
+	Type() string
+
+	// Goal is the investment goal associated with the security, such as
+	// "Growth" or "Income".
+	Goal() string
+
+	// RawRecord gives access to the original data of the security.
+	RawRecord
+}
+
+type security struct {
+	rawData
+	name         string
+	symbol       string
+	securityType string
+	goal         string
+}
+
+func (s *security) Name() string {
+	return s.name
+}
+
+func (s *security) Symbol() string {
+	return s.symbol
+}
+
+func (s *security) Type() string {
+	return s.securityType
+}
+
+func (s *security) Goal() string {
+	return s.goal
+}
+
+func (s *security) parseSecurityField(line string, config Config) error {
+	if line == "" {
+		return errors.New("line is empty")
+	}
+
+	switch line[0] {
+	case 'N':
+		s.name = line[1:]
+		return nil
+
+	case 'S':
+		s.symbol = line[1:]
+		return nil
+
+	case 'T':
+		s.securityType = line[1:]
+		return nil
+
+	case 'G':
+		s.goal = line[1:]
+		return nil
+
+	default:
+		return UnsupportedFieldError{Line: line}
+	}
+}
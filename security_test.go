@@ -0,0 +1,49 @@
+//   Copyright 2018 Duncan Jones
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package qif
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestSecurityFields(t *testing.T) {
+	s := &security{}
+
+	for _, l := range []string{"NAcme Corp", "SACME", "TStock", "GGrowth"} {
+		err := s.parseSecurityField(l, Config{})
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, "Acme Corp", s.Name())
+	assert.Equal(t, "ACME", s.Symbol())
+	assert.Equal(t, "Stock", s.Type())
+	assert.Equal(t, "Growth", s.Goal())
+}
+
+func TestBadSecurityLine(t *testing.T) {
+	s := &security{}
+	err := s.parseSecurityField("Z1234", Config{})
+
+	_, ok := err.(UnsupportedFieldError)
+	assert.True(t, ok)
+}
+
+func TestSecurityEmptyLine(t *testing.T) {
+	s := &security{}
+	err := s.parseSecurityField("", Config{})
+	assert.Error(t, err)
+}
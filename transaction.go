@@ -23,6 +23,7 @@ import (
 	"regexp"
 
 	"strings"
+	"unicode"
 
 	"github.com/pkg/errors"
 )
@@ -36,8 +37,6 @@ const (
 	NotCleared                  = iota
 )
 
-type UnsupportedFieldError error
-
 // A Transaction contains the fields common to all transaction types.
 type Transaction interface {
 
@@ -45,9 +44,16 @@ type Transaction interface {
 	// fields are zero.
 	Date() time.Time
 
-	// Amount stores the transaction value in minor currency units. For
-	// instance, a $12.99 transaction will be 1299.
-	Amount() int
+	// Amount stores the transaction value. Its scale is the number of decimal
+	// places used by Currency, so a $12.99 transaction has coefficient 1299
+	// and scale 2, while a ¥1299 transaction has coefficient 1299 and scale
+	// 0.
+	Amount() Decimal
+
+	// Currency is the ISO 4217 code of the transaction's currency, taken from
+	// Config.AccountCurrencies or Config.Currency. It is empty if no currency
+	// was configured, in which case amounts have two decimal places.
+	Currency() string
 
 	// Memo is a string description of the transaction.
 	Memo() string
@@ -56,23 +62,49 @@ type Transaction interface {
 	// UnknownStatus if the transaction data did not specify a value for this
 	// field.
 	Status() ClearedStatus
+
+	// Account is the account that owns the transaction. It is nil if the
+	// input data did not contain an account record before the transaction.
+	Account() Account
+
+	// AccountType indicates the type of register the transaction was read
+	// from, based on the most recent section header. The value will be
+	// UnknownAccountType for memorized transactions, which do not belong to a
+	// register.
+	AccountType() AccountType
+
+	// Extensions holds the results of the handlers registered with
+	// Config.HandleField, keyed by field code. It is nil if no handlers ran.
+	Extensions() map[byte]interface{}
+
+	// RawRecord gives access to the original data of the transaction.
+	RawRecord
 }
 
 type transaction struct {
-	date   time.Time
-	amount int
-	memo   string
-	status ClearedStatus
+	rawData
+	date        time.Time
+	amount      Decimal
+	currency    string
+	memo        string
+	status      ClearedStatus
+	account     Account
+	accountType AccountType
+	extensions  map[byte]interface{}
 }
 
 func (t *transaction) Date() time.Time {
 	return t.date
 }
 
-func (t *transaction) Amount() int {
+func (t *transaction) Amount() Decimal {
 	return t.amount
 }
 
+func (t *transaction) Currency() string {
+	return t.currency
+}
+
 func (t *transaction) Memo() string {
 	return t.memo
 }
@@ -81,6 +113,26 @@ func (t *transaction) Status() ClearedStatus {
 	return t.status
 }
 
+func (t *transaction) Account() Account {
+	return t.account
+}
+
+func (t *transaction) AccountType() AccountType {
+	return t.accountType
+}
+
+func (t *transaction) Extensions() map[byte]interface{} {
+	return t.extensions
+}
+
+// setExtension stores the result of a field handler.
+func (t *transaction) setExtension(code byte, value interface{}) {
+	if t.extensions == nil {
+		t.extensions = make(map[byte]interface{})
+	}
+	t.extensions[code] = value
+}
+
 func (t *transaction) parseTransactionField(line string, config Config) error {
 	if line == "" {
 		return errors.New("line is empty")
@@ -88,7 +140,7 @@ func (t *transaction) parseTransactionField(line string, config Config) error {
 
 	switch line[0] {
 	case 'D':
-		date, err := parseDate(line[1:], config.DayFirst)
+		date, err := parseDate(line[1:], config)
 		if err != nil {
 			return errors.Wrap(err, "failed to parse date")
 		}
@@ -96,7 +148,7 @@ func (t *transaction) parseTransactionField(line string, config Config) error {
 		return nil
 
 	case 'T', 'U': // Wikipedia suggests 'U' is a synonym for 'T'
-		amt, err := parseAmount(line[1:])
+		amt, err := parseAmount(line[1:], t.currency, config)
 		if err != nil {
 			return errors.Wrap(err, "failed to parse amount")
 		}
@@ -108,7 +160,7 @@ func (t *transaction) parseTransactionField(line string, config Config) error {
 		return nil
 
 	case 'C':
-		status, err := parseClearedStatus(line[1:])
+		status, err := parseClearedStatus(strings.TrimSpace(line[1:]))
 		if err != nil {
 			return errors.Wrap(err, "failed to parse cleared status")
 		}
@@ -116,7 +168,7 @@ func (t *transaction) parseTransactionField(line string, config Config) error {
 		return nil
 
 	default:
-		return UnsupportedFieldError(errors.Errorf("cannot process line '%s'", line))
+		return UnsupportedFieldError{Line: line}
 	}
 }
 
@@ -134,57 +186,219 @@ func parseClearedStatus(s string) (ClearedStatus, error) {
 	}
 }
 
-// parseAmount converts an amount string (such as '12.99') into minor currency
-// units.
-func parseAmount(s string) (int, error) {
+// parseAmount converts an amount string (such as '12.99') into a Decimal with
+// the number of decimal places of the given currency. The decimal and
+// grouping separators are taken from config. Currency symbols are ignored and
+// amounts in parentheses are negative.
+func parseAmount(s string, currency string, config Config) (Decimal, error) {
+	digits, err := MinorUnits(currency)
+	if err != nil {
+		return Decimal{}, err
+	}
 
-	sMod := strings.Replace(s, ",", "", -1)
+	// Expect either a whole number or a decimal with no more numbers after
+	// the point than the currency allows.
+	fraction := ""
+	if digits > 0 {
+		fraction = fmt.Sprintf(`\d{1,%d}`, digits)
+	}
 
-	// Check format. Expect an optional minus or plus sign, then either a whole
-	// number or a decimal with numbers either side of the point.
-	re := regexp.MustCompile(`[\-\+]?\d+\.\d{1,2}`)
+	sMod, err := cleanNumber(s, config, `\d+`, fraction)
+	if err != nil {
+		return Decimal{}, errors.Errorf(`bad amount string "%s"`, s)
+	}
 
-	if !re.MatchString(sMod) {
-		return 0, errors.Errorf(`bad amount string "%s"`, s)
+	amt, err := ParseDecimal(sMod)
+	if err != nil {
+		return Decimal{}, errors.Wrapf(err, `bad amount string "%s"`, s)
 	}
 
-	// If there's only one number after the decimal point, add a zero
-	if strings.Index(sMod, ".") == len(sMod)-2 {
-		sMod = sMod + "0"
+	// Pad the number of digits after the decimal point, which overflows if
+	// the amount has too many digits in total
+	amt, err = checkOverflow(func() Decimal {
+		return amt.Round(digits, RoundDown)
+	})
+	if err != nil {
+		return Decimal{}, errors.Wrapf(err, `bad amount string "%s"`, s)
 	}
 
-	return strconv.Atoi(strings.Replace(sMod, ".", "", 1))
+	return amt, nil
 }
 
+// parseNumber converts a decimal string (such as '1,234.5678') into a
+// Decimal, keeping all the decimal places given. It is used for values that
+// are not currency amounts, such as share prices and quantities.
+func parseNumber(s string, config Config) (Decimal, error) {
+
+	// Expect digits with an optional decimal point.
+	sMod, err := cleanNumber(s, config, `\d*`, `\d*`)
+	if err != nil {
+		return Decimal{}, errors.Errorf(`bad number string "%s"`, s)
+	}
+
+	number, err := ParseDecimal(sMod)
+	if err != nil {
+		return Decimal{}, errors.Wrapf(err, `bad number string "%s"`, s)
+	}
+
+	return number, nil
+}
+
+// numberFormat returns the decimal and grouping separators specified by
+// config, applying the defaults for any that are not set.
+func numberFormat(config Config) (decimal, group rune) {
+	decimal = config.DecimalSeparator
+	if decimal == 0 {
+		decimal = '.'
+	}
+
+	group = config.GroupSeparator
+	if group == 0 {
+		group = ','
+		if decimal == ',' {
+			group = '.'
+		}
+	}
+
+	return
+}
+
+// cleanNumber validates a number and converts it into a form understood by
+// strconv (e.g. "-1234.5"). It removes currency symbols and grouping
+// separators, and converts accounting negatives such as "(12.00)". The
+// integer and fraction patterns describe the digits either side of the
+// decimal separator. An empty fraction pattern means no decimal separator is
+// allowed. Grouped integers must use groups of three digits.
+func cleanNumber(s string, config Config, integer, fraction string) (string,
+	error) {
+	decimal, group := numberFormat(config)
+
+	sMod := strings.TrimSpace(s)
+
+	negative := false
+	if strings.HasPrefix(sMod, "(") && strings.HasSuffix(sMod, ")") {
+		negative = true
+		sMod = sMod[1 : len(sMod)-1]
+	}
+
+	isSymbol := func(r rune) bool {
+		return unicode.Is(unicode.Sc, r) || unicode.IsSpace(r)
+	}
+
+	// The sign may come before or after a currency symbol
+	sMod = strings.TrimFunc(sMod, isSymbol)
+	sign := ""
+	if strings.HasPrefix(sMod, "-") || strings.HasPrefix(sMod, "+") {
+		sign = sMod[:1]
+		sMod = strings.TrimLeftFunc(sMod[1:], isSymbol)
+	}
+
+	if negative && sign != "" {
+		return "", errors.Errorf(`bad number string "%s"`, s)
+	}
+
+	if negative {
+		sign = "-"
+	}
+
+	d := regexp.QuoteMeta(string(decimal))
+	g := regexp.QuoteMeta(string(group))
+	pattern := fmt.Sprintf(`^(\d{1,3}(%s\d{3})+|%s)`, g, integer)
+	if fraction != "" {
+		pattern += fmt.Sprintf(`(%s%s)?`, d, fraction)
+	}
+	re := regexp.MustCompile(pattern + "$")
+
+	if !re.MatchString(sMod) {
+		return "", errors.Errorf(`bad number string "%s"`, s)
+	}
+
+	sMod = strings.Replace(sMod, string(group), "", -1)
+	sMod = strings.Replace(sMod, string(decimal), ".", 1)
+	return sign + sMod, nil
+}
+
+// dateYear splits a date into the year and everything before it. Quicken
+// uses an apostrophe before the year to indicate dates after 1999, padding
+// single digit years with a space (e.g. "1/ 2' 5").
+var dateYear = regexp.MustCompile(`^(.*?)\s*('?)(\s*)(\d{1,4})\s*$`)
+
+// isoDate matches dates with the year first (e.g. 2024-03-15).
+var isoDate = regexp.MustCompile(
+	`^\s*(\d{4})[\-/.](\d{1,2})[\-/.](\d{1,2})\s*$`)
+
+// dayToken matches the day of a date written with a month name, including any
+// ordinal suffix (e.g. "1st" or "1er").
+var dayToken = regexp.MustCompile(`^(\d{1,2})\pL*$`)
+
 // parseDate attempts to parse the given string with a variety of formats.
-// monthFirst controls whether mm/dd or dd/mm formats are used.
-func parseDate(s string, dayFirst bool) (date time.Time, err error) {
+// config.DayFirst controls whether mm/dd or dd/mm formats are used, while
+// config.CenturyPivot and config.ReferenceDate control the expansion of short
+// years. Custom layouts and month names are also taken from config.
+func parseDate(s string, config Config) (date time.Time, err error) {
+	if config.ParseDate != nil {
+		return config.ParseDate(s)
+	}
+
+	for _, layout := range config.DateLayouts {
+		date, err = time.Parse(layout, strings.TrimSpace(s))
+		if err == nil {
+			return
+		}
+	}
+
 	// The spec is vague on date formats. Based on wikipedia and other sources,
 	// this is a potential list of valid options (using Go reference time of
 	// Mon Jan 2 15:04:05 -0700 MST 2006).
 
+	// Dates with the year first are unambiguous, so try these first
+	if m := isoDate.FindStringSubmatch(s); m != nil {
+		date, err = time.Parse("2006/1/2", strings.Join(m[1:], "/"))
+		if err != nil {
+			err = errors.Errorf(`failed to parse date "%s"`, s)
+		}
+		return
+	}
+
+	// Go's handling of short years depends on a fixed pivot and cannot cope
+	// with single digit years, so expand the year before parsing.
+	m := dateYear.FindStringSubmatch(s)
+	if m == nil {
+		err = errors.Errorf(`failed to parse date "%s"`, s)
+		return
+	}
+
+	year, err := expandYear(m[4], m[2] != "", m[3] != "", config)
+	if err != nil {
+		err = errors.Wrapf(err, `failed to parse date "%s"`, s)
+		return
+	}
+
 	// Dates and months may or may not have leading zeroes. To reduce
 	// permutations, remove any leading zeros:
 	re := regexp.MustCompile(`(^|[^\d])0`)
-	sMod := re.ReplaceAllString(s, "$1")
+	sMod := re.ReplaceAllString(m[1], "$1")
+
+	// Numeric dates may be separated by slashes, dots or dashes
+	re = regexp.MustCompile(`^[\d/.\- ]+$`)
+	if !re.MatchString(sMod) {
+		return parseMonthNameDate(m[1], year, config.MonthNames, s)
+	}
 
 	// Some of the examples have spaces between days and months, in numeric
 	// form. Let's remove all spaces to be safe.
-	re = regexp.MustCompile(`^[\d/ ]+$`)
-	if re.MatchString(sMod) {
-		sMod = strings.Replace(sMod, " ", "", -1)
-	}
+	sMod = strings.Replace(sMod, " ", "", -1)
+	sMod = strings.NewReplacer(".", "/", "-", "/").Replace(sMod)
 
-	// Go's date parsing doesn't seem to cope with single digit years.
-	re = regexp.MustCompile(`'(\d)$`)
-	if re.MatchString(sMod) {
-		now := time.Now()
-		decimal := (now.Year() - 2000) / 10
-		sMod = re.ReplaceAllString(sMod, fmt.Sprintf("%d$1", decimal))
+	// The apostrophe form has no separator before the year
+	if !strings.HasSuffix(sMod, "/") {
+		sMod += "/"
 	}
 
+	sMod += strconv.Itoa(year)
+
 	var first, second string
-	if dayFirst {
+	if config.DayFirst {
 		first = "2"
 		second = "1"
 	} else {
@@ -192,21 +406,98 @@ func parseDate(s string, dayFirst bool) (date time.Time, err error) {
 		second = "2"
 	}
 
-	formats := []string{
-		"2 January 2006",
-		"2 January 06",
+	date, err = time.Parse(fmt.Sprintf("%s/%s/2006", first, second), sMod)
+	if err != nil {
+		err = errors.Errorf(`failed to parse date "%s"`, s)
+	}
+	return
+}
 
-		fmt.Sprintf("%s/%s/2006", first, second),
-		fmt.Sprintf("%s/%s/06", first, second),
+// parseMonthNameDate parses the day and month of a date containing a month
+// name, such as "15 March", "15-Mar" or "March 15,". The day may come before
+// or after the month. English month names are always recognised, in addition
+// to those in names. original is used for error messages.
+func parseMonthNameDate(s string, year int, names MonthNames,
+	original string) (time.Time, error) {
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return unicode.IsSpace(r) || strings.ContainsRune("-./,", r)
+	})
+
+	if len(fields) != 2 {
+		return time.Time{}, errors.Errorf(`failed to parse date "%s"`, original)
 	}
 
-	for _, f := range formats {
-		date, err = time.Parse(f, sMod)
-		if err == nil {
-			return
+	var (
+		dayField, monthField string
+		month                time.Month
+		ok                   bool
+	)
+
+	for _, order := range [][2]int{{0, 1}, {1, 0}} {
+		dayField, monthField = fields[order[0]], fields[order[1]]
+		month, ok = names.lookup(monthField)
+		if ok {
+			break
 		}
 	}
 
-	err = errors.Errorf(`failed to parse date "%s"`, s)
-	return
+	m := dayToken.FindStringSubmatch(dayField)
+	if !ok || m == nil {
+		return time.Time{}, errors.Errorf(`failed to parse date "%s"`, original)
+	}
+
+	day, _ := strconv.Atoi(m[1])
+	date := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+
+	// time.Date normalises invalid days (e.g. 31 April becomes 1 May)
+	if date.Day() != day {
+		return time.Time{}, errors.Errorf(`failed to parse date "%s"`, original)
+	}
+
+	return date, nil
+}
+
+// expandYear converts the year of a date into a four digit year. apostrophe
+// indicates the year was preceded by an apostrophe, while padded indicates it
+// was also padded with spaces.
+func expandYear(digits string, apostrophe, padded bool, config Config) (int,
+	error) {
+	year, err := strconv.Atoi(digits)
+	if err != nil {
+		return 0, err
+	}
+
+	switch {
+	case len(digits) == 4:
+		return year, nil
+
+	case len(digits) == 3:
+		return 0, errors.Errorf(`bad year "%s"`, digits)
+
+	case apostrophe && len(digits) == 1 && !padded:
+		// A single digit year within the decade of the reference date, which
+		// cannot be before 2000
+		ref := 2000
+		if config.ReferenceDate.Year() > ref {
+			ref = config.ReferenceDate.Year()
+		}
+		return ref - ref%10 + year, nil
+
+	case apostrophe:
+		// Quicken uses apostrophes for years after 1999
+		return 2000 + year, nil
+
+	default:
+		// Place the year in the hundred years starting at the pivot
+		pivot := config.CenturyPivot
+		if pivot == 0 {
+			pivot = defaultCenturyPivot
+		}
+
+		year += pivot - pivot%100
+		if year < pivot {
+			year += 100
+		}
+		return year, nil
+	}
 }
@@ -15,8 +15,10 @@
 package qif
 
 import (
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"strings"
 	"testing"
 	"time"
 )
@@ -36,36 +38,321 @@ func TestDateParse(t *testing.T) {
 		"03/1/2017",
 	}
 
+	// Single digit years are relative to the reference date
+	config := Config{
+		ReferenceDate: time.Date(2018, time.January, 1, 0, 0, 0, 0, time.UTC),
+	}
+
 	for _, i := range inputs {
-		date, err := parseDate(i, false)
+		date, err := parseDate(i, config)
 		assert.NoError(t, err)
 		assert.Equalf(t, expectedDate, date, "failed for input %s", i)
 	}
 }
 
-func TestAmountParse(t *testing.T) {
+func TestDateParseFormats(t *testing.T) {
+	expectedDate := time.Date(2024, time.March, 15, 0, 0, 0, 0, time.UTC)
+
+	inputs := []string{
+		"2024-03-15",
+		"2024/3/15",
+		"2024.03.15",
+		"15.03.2024",
+		"15.3.24",
+		"15-03-2024",
+		"15-Mar-2024",
+		"15-MAR-24",
+		"15 Mar 2024",
+		"15 March '24",
+		"March 15, 2024",
+		"Mar. 15 2024",
+		"15th March 2024",
+	}
+
+	for _, i := range inputs {
+		date, err := parseDate(i, Config{DayFirst: true})
+		assert.NoErrorf(t, err, "failed for input %s", i)
+		assert.Equalf(t, expectedDate, date, "failed for input %s", i)
+	}
+
+	badInputs := []string{
+		"2024-02-30",
+		"30 February 2024",
+		"15 Foo 2024",
+		"15 March April 2024",
+		"15.15.2024",
+	}
+
+	for _, i := range badInputs {
+		_, err := parseDate(i, Config{DayFirst: true})
+		assert.Errorf(t, err, "failed for input %s", i)
+	}
+}
+
+func TestDateParseMonthNames(t *testing.T) {
+	vectors := map[string]MonthNames{
+		"15. März 2024":     GermanMonths,
+		"15-Mär-2024":       GermanMonths,
+		"15 mars 2024":      FrenchMonths,
+		"15-marzo-2024":     SpanishMonths,
+		"15 marzo 2024":     ItalianMonths,
+		"15 maart 2024":     DutchMonths,
+		"15 Mar 2024":       GermanMonths,
+		"15 DÉCEMBRE 2024":  FrenchMonths,
+		"1er décembre 2024": FrenchMonths,
+		"1 décembre 2024":   nil,
+	}
+
+	for k, v := range vectors {
+		date, err := parseDate(k, Config{MonthNames: v})
+		if v == nil {
+			assert.Errorf(t, err, "failed for input %s", k)
+			continue
+		}
+		assert.NoErrorf(t, err, "failed for input %s", k)
+		assert.Equalf(t, 2024, date.Year(), "failed for input %s", k)
+	}
+}
+
+func TestDateParseCustom(t *testing.T) {
+	expectedDate := time.Date(2024, time.March, 15, 0, 0, 0, 0, time.UTC)
+
+	date, err := parseDate("20240315", Config{DateLayouts: []string{"20060102"}})
+	require.NoError(t, err)
+	assert.Equal(t, expectedDate, date)
+
+	// Built-in formats are still used if the layouts don't match
+	date, err = parseDate("3/15/24", Config{DateLayouts: []string{"20060102"}})
+	require.NoError(t, err)
+	assert.Equal(t, expectedDate, date)
+
+	config := Config{
+		ParseDate: func(s string) (time.Time, error) {
+			return expectedDate, nil
+		},
+	}
+
+	date, err = parseDate("anything", config)
+	require.NoError(t, err)
+	assert.Equal(t, expectedDate, date)
+}
+
+func TestDateParseYears(t *testing.T) {
+	vectors := map[string]int{
+		"1/2/2005":   2005,
+		"1/2/05":     2005,
+		"1/2/68":     2068,
+		"1/2/69":     1969,
+		"6/ 1/94":    1994,
+		"1/ 2'05":    2005,
+		"1/2'05":     2005,
+		"1/2' 5":     2005,
+		"1/2'5":      2005,
+		"1/2/'5":     2005,
+		"1/2'15":     2015,
+		"2 March 94": 1994,
+		"2 March '5": 2005,
+	}
+
+	for k, v := range vectors {
+		date, err := parseDate(k, Config{})
+		assert.NoErrorf(t, err, "failed for input %s", k)
+		assert.Equalf(t, v, date.Year(), "failed for input %s", k)
+	}
+}
+
+func TestDateParseCenturyPivot(t *testing.T) {
+	config := Config{CenturyPivot: 1950}
+
 	vectors := map[string]int{
-		"12.99":  1299,
-		"+12.99": 1299,
-		"-12.99": -1299,
-		"-12.9":  -1290,
+		"1/2/49":  2049,
+		"1/2/50":  1950,
+		"1/2/99":  1999,
+		"1/2/00":  2000,
+		"1/2'99":  2099,
+		"1/2/'5":  2005,
+		"1/2/123": 0,
+	}
+
+	for k, v := range vectors {
+		date, err := parseDate(k, config)
+		if v == 0 {
+			assert.Errorf(t, err, "failed for input %s", k)
+			continue
+		}
+		assert.NoErrorf(t, err, "failed for input %s", k)
+		assert.Equalf(t, v, date.Year(), "failed for input %s", k)
+	}
+}
+
+func TestDateParseReferenceDate(t *testing.T) {
+	vectors := map[int]int{
+		1995: 2005,
+		2009: 2005,
+		2027: 2025,
+	}
+
+	for k, v := range vectors {
+		config := Config{
+			ReferenceDate: time.Date(k, time.June, 1, 0, 0, 0, 0, time.UTC),
+		}
+
+		date, err := parseDate("1/2'5", config)
+		assert.NoError(t, err)
+		assert.Equalf(t, v, date.Year(), "failed for reference %d", k)
+
+		// Padded years are unaffected
+		date, err = parseDate("1/2' 5", config)
+		assert.NoError(t, err)
+		assert.Equal(t, 2005, date.Year())
+	}
+}
+
+func TestAmountParse(t *testing.T) {
+	vectors := map[string]Decimal{
+		"12.99":      dec("12.99"),
+		"+12.99":     dec("12.99"),
+		"-12.99":     dec("-12.99"),
+		"-12.9":      dec("-12.90"),
+		"12":         dec("12.00"),
+		"1,234.56":   dec("1234.56"),
+		"$12.99":     dec("12.99"),
+		"-$12.99":    dec("-12.99"),
+		"$-12.99":    dec("-12.99"),
+		"12.99 €":    dec("12.99"),
+		"(45.00)":    dec("-45.00"),
+		"($1,000.5)": dec("-1000.50"),
 	}
 
 	for k, v := range vectors {
-		res, err := parseAmount(k)
+		res, err := parseAmount(k, "", Config{})
 		assert.NoErrorf(t, err, "error processing '%s'", k)
 		assert.Equalf(t, v, res, "error processing '%s", k)
 	}
 
 	badVectors := []string{
 		"12.",
-		"12",
 		".9",
 		"+-12.00",
+		"12.99abc",
+		"12.999",
+		"(-45.00)",
+		"12,99",
+		"1,00.00",
+		"1,2345.00",
+		"",
+	}
+
+	for _, v := range badVectors {
+		_, err := parseAmount(v, "", Config{})
+		assert.Errorf(t, err, "error processing '%s'", v)
+	}
+}
+
+func TestAmountParseDecimalComma(t *testing.T) {
+	config := Config{DecimalSeparator: ','}
+
+	vectors := map[string]Decimal{
+		"1.234,56": dec("1234.56"),
+		"-12,9":    dec("-12.90"),
+		"12":       dec("12.00"),
+		"12,99 €":  dec("12.99"),
+	}
+
+	for k, v := range vectors {
+		res, err := parseAmount(k, "", config)
+		assert.NoErrorf(t, err, "error processing '%s'", k)
+		assert.Equalf(t, v, res, "error processing '%s", k)
+	}
+
+	_, err := parseAmount("1,234.56", "", config)
+	assert.Error(t, err)
+
+	// Groups separated by spaces, as used in France
+	config.GroupSeparator = ' '
+	res, err := parseAmount("1 234,56", "", config)
+	require.NoError(t, err)
+	assert.Equal(t, dec("1234.56"), res)
+}
+
+func TestAmountParseOverflow(t *testing.T) {
+	// The largest coefficient is valid at scale 0, but not with two decimal
+	// places
+	_, err := parseAmount("9223372036854775807", "JPY", Config{})
+	require.NoError(t, err)
+
+	_, err = parseAmount("9223372036854775807", "", Config{})
+	require.Error(t, err)
+	assert.Equal(t, ErrDecimalOverflow, errors.Cause(err))
+
+	_, err = NewReader(strings.NewReader(bankHeader +
+		"\nT922337203685477580\n^")).Read()
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrDecimalOverflow))
+	assert.Contains(t, err.Error(), "bad amount string")
+}
+
+func TestAmountParseCurrency(t *testing.T) {
+	vectors := map[string]Decimal{
+		"1,299":    dec("1299"),
+		"-500":     dec("-500"),
+		"¥1,299":   dec("1299"),
+		"12.345":   dec("12.345"),
+		"12.3":     dec("12.300"),
+		"-1,000.5": dec("-1000.500"),
+	}
+
+	currencies := map[string]string{
+		"1,299":    "JPY",
+		"-500":     "JPY",
+		"¥1,299":   "JPY",
+		"12.345":   "KWD",
+		"12.3":     "KWD",
+		"-1,000.5": "BHD",
+	}
+
+	for k, v := range vectors {
+		res, err := parseAmount(k, currencies[k], Config{})
+		assert.NoErrorf(t, err, "error processing '%s'", k)
+		assert.Equalf(t, v, res, "error processing '%s", k)
+	}
+
+	_, err := parseAmount("12.99", "JPY", Config{})
+	assert.Error(t, err)
+
+	_, err = parseAmount("12.3456", "KWD", Config{})
+	assert.Error(t, err)
+
+	_, err = parseAmount("12.99", "XYZ", Config{})
+	assert.Error(t, err)
+}
+
+func TestNumberParse(t *testing.T) {
+	vectors := map[string]Decimal{
+		"12":         dec("12"),
+		"12.3456":    dec("12.3456"),
+		"-0.5":       dec("-0.5"),
+		".5":         dec("0.5"),
+		"+1,234.125": dec("1234.125"),
+		"$101.10":    dec("101.10"),
+	}
+
+	for k, v := range vectors {
+		res, err := parseNumber(k, Config{})
+		assert.NoErrorf(t, err, "error processing '%s'", k)
+		assert.Equalf(t, v, res, "error processing '%s", k)
+	}
+
+	badVectors := []string{
+		"",
+		"abc",
+		"1.2.3",
+		"12x",
+		"NaN",
 	}
 
 	for _, v := range badVectors {
-		_, err := parseAmount(v)
+		_, err := parseNumber(v, Config{})
 		assert.Errorf(t, err, "error processing '%s'", v)
 	}
 }
@@ -121,7 +408,7 @@ func TestParseTransactionAmountT(t *testing.T) {
 	err := tx.parseTransactionField("T12.99", Config{})
 	require.NoError(t, err)
 
-	require.Equal(t, 1299, tx.Amount())
+	require.Equal(t, NewDecimal(1299, 2), tx.Amount())
 }
 
 func TestParseTransactionAmountU(t *testing.T) {
@@ -129,7 +416,7 @@ func TestParseTransactionAmountU(t *testing.T) {
 	err := tx.parseTransactionField("U12.99", Config{})
 	require.NoError(t, err)
 
-	require.Equal(t, 1299, tx.Amount())
+	require.Equal(t, NewDecimal(1299, 2), tx.Amount())
 }
 
 func TestParseTransactionMemo(t *testing.T) {
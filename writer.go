@@ -0,0 +1,395 @@
+//   Copyright 2018 Duncan Jones
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package qif
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// A Writer serialises transactions as QIF data.
+type Writer interface {
+
+	// Write writes a single transaction. A header line is written first if
+	// the transaction belongs to a different account or type of register than
+	// the previous transaction. Amounts are written with the number of
+	// decimal places used by the transaction's currency. Output is buffered,
+	// so Flush must be called once writing is complete.
+	Write(tx Transaction) error
+
+	// WriteAll writes all the transactions and then calls Flush.
+	WriteAll(txs []Transaction) error
+
+	// Flush writes any buffered data to the underlying io.Writer and returns
+	// the first error encountered while writing, if any.
+	Flush() error
+}
+
+// writer implements Writer. Construct using NewWriter or NewWriterWithConfig.
+type writer struct {
+
+	// out buffers the output.
+	out *bufio.Writer
+
+	// config defines the behaviour of the writer. DayFirst controls the
+	// format of dates, DecimalSeparator the format of amounts and Encoding the
+	// character encoding.
+	config Config
+
+	// started is true once any output has been written.
+	started bool
+
+	// header is the last header line written, or empty if none has been
+	// written.
+	header string
+
+	// account is the last account written, or nil if none has been written.
+	account Account
+}
+
+// NewWriter creates a new Writer with a default configuration (see
+// DefaultConfig).
+func NewWriter(w io.Writer) *writer {
+	return NewWriterWithConfig(w, DefaultConfig())
+}
+
+// NewWriterWithConfig creates a new Writer with the specified configuration.
+func NewWriterWithConfig(w io.Writer, config Config) *writer {
+	return &writer{
+		out:    bufio.NewWriter(w),
+		config: config,
+	}
+}
+
+// Write implements Writer.Write.
+func (w *writer) Write(tx Transaction) error {
+	var header string
+
+	switch tx.(type) {
+	case MemorizedTransaction:
+		header = memorizedHeader
+	case BankingTransaction:
+		header = registerHeader(tx.AccountType(), bankHeader)
+	case InvestmentTransaction:
+		header = investmentHeader
+	default:
+		return errors.Errorf("unsupported transaction type %T", tx)
+	}
+
+	digits, err := MinorUnits(tx.Currency())
+	if err != nil {
+		return errors.Wrap(err, "failed to write transaction")
+	}
+
+	if acct := tx.Account(); acct != nil && acct != w.account {
+		err := w.writeAccount(acct)
+		if err != nil {
+			return err
+		}
+		w.account = acct
+
+		// Each account's transactions need their own header
+		w.header = ""
+	}
+
+	if header != w.header {
+		w.writeLine(header)
+		w.header = header
+	}
+
+	w.writeTransactionFields(tx, digits)
+
+	switch t := tx.(type) {
+	case MemorizedTransaction:
+		w.writeBankingTransactionFields(t, digits)
+		w.writeMemorizedTransactionFields(t, digits)
+	case BankingTransaction:
+		w.writeBankingTransactionFields(t, digits)
+	case InvestmentTransaction:
+		w.writeInvestmentTransactionFields(t, digits)
+	}
+
+	w.writeExtraFields(tx)
+	w.writeLine(recordEnd)
+	return nil
+}
+
+// WriteAll implements Writer.WriteAll.
+func (w *writer) WriteAll(txs []Transaction) error {
+	for _, tx := range txs {
+		err := w.Write(tx)
+		if err != nil {
+			return err
+		}
+	}
+
+	return w.Flush()
+}
+
+// Flush implements Writer.Flush.
+func (w *writer) Flush() error {
+	return w.out.Flush()
+}
+
+// writeLine writes a line of output. Errors are retained by the underlying
+// bufio.Writer and reported by Flush.
+func (w *writer) writeLine(line string) {
+	if !w.started {
+		switch w.config.Encoding {
+		case UTF16LE:
+			w.out.Write(utf16LEBOM)
+		case UTF16BE:
+			w.out.Write(utf16BEBOM)
+		}
+		w.started = true
+	}
+
+	w.out.Write(encodeLine(line+"\n", w.config.Encoding))
+}
+
+// writeField writes a field line, unless the value is empty.
+func (w *writer) writeField(code byte, value string) {
+	if value != "" {
+		w.writeLine(string(code) + value)
+	}
+}
+
+func (w *writer) writeAccount(a Account) error {
+	digits, err := MinorUnits(a.Currency())
+	if err != nil {
+		return errors.Wrap(err, "failed to write account")
+	}
+
+	w.writeLine(accountHeader)
+	w.writeField('N', a.Name())
+	w.writeField('T', a.Type())
+	w.writeField('D', a.Description())
+
+	if !a.CreditLimit().IsZero() {
+		w.writeField('L', w.amount(a.CreditLimit(), digits))
+	}
+
+	if !a.Balance().IsZero() {
+		w.writeField('$', w.amount(a.Balance(), digits))
+	}
+
+	if !a.BalanceDate().IsZero() {
+		w.writeField('/', formatDate(a.BalanceDate(), w.config.DayFirst))
+	}
+
+	w.writeExtraFields(a)
+	w.writeLine(recordEnd)
+	return nil
+}
+
+// writeExtraFields writes the unknown fields kept by the reader, in their
+// original order.
+func (w *writer) writeExtraFields(rec RawRecord) {
+	for _, f := range rec.Extra() {
+		w.writeLine(string(f.Code) + f.Value)
+	}
+}
+
+func (w *writer) writeTransactionFields(tx Transaction, digits int) {
+	if !tx.Date().IsZero() {
+		w.writeField('D', formatDate(tx.Date(), w.config.DayFirst))
+	}
+
+	w.writeField('T', w.amount(tx.Amount(), digits))
+
+	switch tx.Status() {
+	case Cleared:
+		w.writeLine("C*")
+	case Reconciled:
+		w.writeLine("CX")
+	case NotCleared:
+		w.writeLine("C")
+	}
+
+	w.writeField('M', tx.Memo())
+}
+
+func (w *writer) writeBankingTransactionFields(tx BankingTransaction,
+	digits int) {
+	w.writeField('N', tx.Num())
+	w.writeField('P', tx.Payee())
+
+	for _, a := range tx.Address() {
+		w.writeLine("A" + a)
+	}
+
+	if tx.AddressMessage() != "" {
+		// The message must be the sixth line, so pad the address if required
+		for i := len(tx.Address()); i < 5; i++ {
+			w.writeLine("A")
+		}
+		w.writeLine("A" + tx.AddressMessage())
+	}
+
+	w.writeField('L', joinCategory(tx.Category(), tx.Class()))
+
+	for _, s := range tx.Splits() {
+		if s.Category != nil {
+			class := ""
+			if s.Class != nil {
+				class = *s.Class
+			}
+			w.writeLine("S" + joinCategory(*s.Category, class))
+		}
+
+		if s.Memo != nil {
+			w.writeLine("E" + *s.Memo)
+		}
+
+		if s.Amount != nil {
+			w.writeLine("$" + w.amount(*s.Amount, digits))
+		}
+
+		if s.Percent != nil {
+			w.writeLine("%" + w.number(*s.Percent) + "%")
+		}
+	}
+}
+
+func (w *writer) writeInvestmentTransactionFields(tx InvestmentTransaction,
+	digits int) {
+	w.writeField('N', tx.Action())
+	w.writeField('Y', tx.Security())
+
+	if !tx.Price().IsZero() {
+		w.writeField('I', w.number(tx.Price()))
+	}
+
+	if !tx.Quantity().IsZero() {
+		w.writeField('Q', w.number(tx.Quantity()))
+	}
+
+	if !tx.Commission().IsZero() {
+		w.writeField('O', w.amount(tx.Commission(), digits))
+	}
+
+	w.writeField('P', tx.Payee())
+	w.writeField('L', tx.TransferAccount())
+
+	if !tx.TransferAmount().IsZero() {
+		w.writeField('$', w.amount(tx.TransferAmount(), digits))
+	}
+}
+
+func (w *writer) writeMemorizedTransactionFields(tx MemorizedTransaction,
+	digits int) {
+	switch tx.Type() {
+	case MemorizedCheck:
+		w.writeLine("KC")
+	case MemorizedDeposit:
+		w.writeLine("KD")
+	case MemorizedPayment:
+		w.writeLine("KP")
+	case MemorizedInvestment:
+		w.writeLine("KI")
+	case MemorizedElectronicPayee:
+		w.writeLine("KE")
+	}
+
+	if !tx.FirstPaymentDate().IsZero() {
+		w.writeField('1', formatDate(tx.FirstPaymentDate(), w.config.DayFirst))
+	}
+
+	if tx.TotalYears() != 0 {
+		w.writeField('2', strconv.Itoa(tx.TotalYears()))
+	}
+
+	if tx.PaymentsMade() != 0 {
+		w.writeField('3', strconv.Itoa(tx.PaymentsMade()))
+	}
+
+	if tx.PeriodsPerYear() != 0 {
+		w.writeField('4', strconv.Itoa(tx.PeriodsPerYear()))
+	}
+
+	if !tx.InterestRate().IsZero() {
+		w.writeField('5', w.number(tx.InterestRate()))
+	}
+
+	if !tx.CurrentBalance().IsZero() {
+		w.writeField('6', w.amount(tx.CurrentBalance(), digits))
+	}
+
+	if !tx.OriginalAmount().IsZero() {
+		w.writeField('7', w.amount(tx.OriginalAmount(), digits))
+	}
+
+	w.writeField('Y', tx.Security())
+
+	if !tx.Price().IsZero() {
+		w.writeField('I', w.number(tx.Price()))
+	}
+
+	if !tx.Quantity().IsZero() {
+		w.writeField('Q', w.number(tx.Quantity()))
+	}
+
+	if !tx.Commission().IsZero() {
+		w.writeField('O', w.amount(tx.Commission(), digits))
+	}
+}
+
+// registerHeader returns the header for a register of the given type, or
+// fallback if the type is unknown.
+func registerHeader(accountType AccountType, fallback string) string {
+	for header, t := range registerHeaders {
+		if t == accountType {
+			return header
+		}
+	}
+
+	return fallback
+}
+
+// amount formats an amount with the given number of decimal places, using the
+// decimal separator specified by the config.
+func (w *writer) amount(amt Decimal, digits int) string {
+	decimal, _ := numberFormat(w.config)
+	return strings.Replace(formatAmount(amt, digits), ".", string(decimal), 1)
+}
+
+// number formats a number using the decimal separator specified by the
+// config.
+func (w *writer) number(d Decimal) string {
+	decimal, _ := numberFormat(w.config)
+	return strings.Replace(d.String(), ".", string(decimal), 1)
+}
+
+// formatAmount converts an amount into a string with the given number of
+// decimal places (such as '12.99'), rounding half to even if the amount has
+// more places than the currency allows.
+func formatAmount(amt Decimal, digits int) string {
+	return amt.Round(digits, RoundHalfEven).String()
+}
+
+// formatDate writes a date with a four digit year. dayFirst controls whether
+// the mm/dd or dd/mm format is used.
+func formatDate(date time.Time, dayFirst bool) string {
+	if dayFirst {
+		return date.Format("02/01/2006")
+	}
+
+	return date.Format("01/02/2006")
+}
@@ -0,0 +1,314 @@
+//   Copyright 2018 Duncan Jones
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package qif
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// roundTrip reads the file, writes the transactions and reads them back.
+func roundTrip(t *testing.T, filename string, config Config) (
+	original, result []Transaction) {
+	input, err := os.Open(filename)
+	require.NoError(t, err)
+	defer input.Close()
+
+	original, err = NewReaderWithConfig(input, config).ReadAll()
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	err = NewWriterWithConfig(&buf, config).WriteAll(original)
+	require.NoError(t, err)
+
+	result, err = NewReaderWithConfig(&buf, config).ReadAll()
+	require.NoError(t, err)
+	return
+}
+
+func TestRoundTripExample1(t *testing.T) {
+	original, result := roundTrip(t, "testdata/example1.qif", Config{})
+	assert.Equal(t, original, result)
+}
+
+func TestRoundTripDayFirst(t *testing.T) {
+	original, result := roundTrip(t, "testdata/example1.qif",
+		Config{DayFirst: true})
+	assert.Equal(t, original, result)
+}
+
+func TestRoundTripInvestment(t *testing.T) {
+	original, result := roundTrip(t, "testdata/investment.qif", Config{})
+	assert.Equal(t, original, result)
+}
+
+func TestRoundTripMemorized(t *testing.T) {
+	input, err := os.Open("testdata/memorized.qif")
+	require.NoError(t, err)
+	defer input.Close()
+
+	// Memorized transactions are not returned by ReadAll
+	var original []Transaction
+	for tx, err := range NewReader(input).MemorizedTransactions() {
+		require.NoError(t, err)
+		original = append(original, tx)
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, NewWriter(&buf).WriteAll(original))
+
+	var result []Transaction
+	for tx, err := range NewReader(&buf).MemorizedTransactions() {
+		require.NoError(t, err)
+		result = append(result, tx)
+	}
+	assert.Equal(t, original, result)
+}
+
+func TestRoundTripAccounts(t *testing.T) {
+	original, result := roundTrip(t, "testdata/accounts.qif", Config{})
+	require.Equal(t, len(original), len(result))
+
+	for i := range original {
+		assert.Equal(t, original[i].Account(), result[i].Account())
+		assert.Equal(t, original[i].Amount(), result[i].Amount())
+	}
+}
+
+func TestWriteBankingTransaction(t *testing.T) {
+	tx := &bankingTransaction{
+		num:            "101",
+		payee:          "Fred",
+		address:        []string{"1 High St"},
+		addressMessage: "Thanks",
+		category:       "Food",
+		splits: []Split{
+			{Category: strptr("Food"), Memo: strptr("lunch"),
+				Amount: amtptr(-1000)},
+			{Category: strptr("Drink"), Amount: amtptr(-299)},
+		},
+	}
+	tx.date = time.Date(2018, time.March, 1, 0, 0, 0, 0, time.UTC)
+	tx.amount = NewDecimal(-1299, 2)
+	tx.memo = "memo"
+	tx.status = Cleared
+
+	var buf bytes.Buffer
+	w := NewWriterWithConfig(&buf, Config{DayFirst: true})
+	require.NoError(t, w.Write(tx))
+	require.NoError(t, w.Flush())
+
+	expected := strings.Join([]string{
+		bankHeader,
+		"D01/03/2018",
+		"T-12.99",
+		"C*",
+		"Mmemo",
+		"N101",
+		"PFred",
+		"A1 High St",
+		"A",
+		"A",
+		"A",
+		"A",
+		"AThanks",
+		"LFood",
+		"SFood",
+		"Elunch",
+		"$-10.00",
+		"SDrink",
+		"$-2.99",
+		recordEnd,
+	}, "\n") + "\n"
+
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestWriteDecimalComma(t *testing.T) {
+	input := strings.Join([]string{
+		bankHeader,
+		"D01/03/2018",
+		"T-1.234,56",
+		"SFood",
+		"$-1.000",
+		"SDrink",
+		"$-234,56",
+		recordEnd,
+	}, "\n")
+
+	config := Config{DayFirst: true, DecimalSeparator: ','}
+	original, err := NewReaderWithConfig(strings.NewReader(input),
+		config).ReadAll()
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	err = NewWriterWithConfig(&buf, config).WriteAll(original)
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), "\nT-1234,56\n")
+	assert.Contains(t, buf.String(), "\n$-1000,00\n")
+
+	result, err := NewReaderWithConfig(&buf, config).ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, original, result)
+}
+
+func TestWriteSplitPercent(t *testing.T) {
+	tx := &bankingTransaction{
+		splits: []Split{
+			{Category: strptr("Food"), Amount: amtptr(-1000),
+				Percent: decptr("80")},
+			{Category: strptr("Drink"), Amount: amtptr(-250),
+				Percent: decptr("20.00")},
+		},
+	}
+	tx.amount = dec("-12.50")
+
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	require.NoError(t, w.Write(tx))
+	require.NoError(t, w.Flush())
+	assert.Contains(t, buf.String(),
+		"\n$-10.00\n%80%\nSDrink\n$-2.50\n%20.00%\n")
+
+	result, err := NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, result, 1)
+	assert.Equal(t, tx.Splits(), result[0].(BankingTransaction).Splits())
+}
+
+func TestWriteClass(t *testing.T) {
+	tx := &bankingTransaction{
+		category: "Food",
+		class:    "Business",
+		splits: []Split{
+			{Category: strptr("Food"), Class: strptr("Business")},
+		},
+	}
+
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	require.NoError(t, w.Write(tx))
+	require.NoError(t, w.Flush())
+
+	assert.Contains(t, buf.String(), "\nLFood/Business\n")
+	assert.Contains(t, buf.String(), "\nSFood/Business\n")
+}
+
+func TestWriteAccountTypeHeader(t *testing.T) {
+	loan := &bankingTransaction{}
+	loan.accountType = LiabilityAccount
+	cash := &bankingTransaction{}
+	cash.accountType = CashAccount
+
+	var buf bytes.Buffer
+	err := NewWriter(&buf).WriteAll([]Transaction{
+		loan,
+		cash,
+		&bankingTransaction{},
+	})
+	require.NoError(t, err)
+
+	expected := strings.Join([]string{
+		liabilityHeader,
+		"T0.00",
+		recordEnd,
+		cashHeader,
+		"T0.00",
+		recordEnd,
+		bankHeader,
+		"T0.00",
+		recordEnd,
+	}, "\n") + "\n"
+
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestWriteHeaderOnce(t *testing.T) {
+	var buf bytes.Buffer
+	err := NewWriter(&buf).WriteAll([]Transaction{
+		&bankingTransaction{},
+		&bankingTransaction{},
+		&investmentTransaction{},
+	})
+	require.NoError(t, err)
+
+	expected := strings.Join([]string{
+		bankHeader,
+		"T0.00",
+		recordEnd,
+		"T0.00",
+		recordEnd,
+		investmentHeader,
+		"T0.00",
+		recordEnd,
+	}, "\n") + "\n"
+
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestFormatAmount(t *testing.T) {
+	vectors := map[int64]string{
+		0:       "0.00",
+		5:       "0.05",
+		-5:      "-0.05",
+		1299:    "12.99",
+		-100000: "-1000.00",
+	}
+
+	for k, v := range vectors {
+		assert.Equal(t, v, formatAmount(NewDecimal(k, 2), 2))
+	}
+
+	assert.Equal(t, "-1299", formatAmount(NewDecimal(-1299, 0), 0))
+	assert.Equal(t, "12.345", formatAmount(NewDecimal(12345, 3), 3))
+	assert.Equal(t, "0.005", formatAmount(NewDecimal(5, 3), 3))
+
+	// Amounts are rounded half to even to the currency's decimal places
+	assert.Equal(t, "12.34", formatAmount(dec("12.345"), 2))
+	assert.Equal(t, "12", formatAmount(dec("12.5"), 0))
+	assert.Equal(t, "12.50", formatAmount(dec("12.5"), 2))
+}
+
+func TestRoundTripCurrencies(t *testing.T) {
+	config := Config{
+		AccountCurrencies: map[string]string{
+			"Yen Account":   "JPY",
+			"Dinar Account": "KWD",
+		},
+	}
+
+	original, result := roundTrip(t, "testdata/currencies.qif", config)
+	require.Equal(t, len(original), len(result))
+
+	for i := range original {
+		assert.Equal(t, original[i].Currency(), result[i].Currency())
+		assert.Equal(t, original[i].Amount(), result[i].Amount())
+		assert.Equal(t, original[i].(BankingTransaction).Splits(),
+			result[i].(BankingTransaction).Splits())
+	}
+}
+
+func TestWriteUnknownCurrency(t *testing.T) {
+	tx := &bankingTransaction{}
+	tx.currency = "XYZ"
+
+	var buf bytes.Buffer
+	assert.Error(t, NewWriter(&buf).Write(tx))
+}